@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+)
+
+// configSection returns the file section for key: the profile given with
+// --profile, or else the section the key belongs in.
+func configSection(args []string) (string, []string) {
+	name, rest := profileFlag(args)
+	if name != "" {
+		return "profile." + name, rest
+	}
+	if len(rest) > 0 {
+		return config.SectionFor(rest[0]), rest
+	}
+	return "", rest
+}
+
+func runConfigGet(args []string) {
+	cfg, args, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: adhanctl config get [--profile NAME] KEY")
+		os.Exit(1)
+	}
+
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(value)
+}
+
+func runConfigSet(args []string) {
+	section, args := configSection(args)
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: adhanctl config set [--profile NAME] KEY VALUE")
+		os.Exit(1)
+	}
+
+	key := args[0]
+	value := strings.Join(args[1:], " ")
+
+	if strings.HasPrefix(section, "profile.") && (key == "profile" || key == "version") {
+		fmt.Fprintf(os.Stderr, "%s can only be set at the top level\n", key)
+		os.Exit(1)
+	}
+	if key == "version" {
+		fmt.Fprintln(os.Stderr, "the config version is managed by adhanctl")
+		os.Exit(1)
+	}
+	if err := config.ValidateSetting(key, value); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	file := readConfigFile()
+	if (key == "profile" || key == "home_profile") && value != "" {
+		if err := checkProfile(file, value); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	file.Set(section, key, value)
+	writeConfigFile(file)
+}
+
+func runConfigUnset(args []string) {
+	section, args := configSection(args)
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: adhanctl config unset [--profile NAME] KEY")
+		os.Exit(1)
+	}
+
+	file := readConfigFile()
+	// Older files may still have the key at the top level.
+	if !file.Unset(section, args[0]) && !file.Unset("", args[0]) {
+		fmt.Fprintf(os.Stderr, "%s is not set in %s\n", args[0], file.Path)
+		os.Exit(1)
+	}
+	writeConfigFile(file)
+}
+
+// runConfigEdit opens a copy of the config in $VISUAL or $EDITOR and only
+// replaces the real file once the copy validates.
+func runConfigEdit(args []string) {
+	file := readConfigFile()
+
+	tmp, err := os.CreateTemp("", "adhanctl-config-*")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating temp file: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(file.Bytes())
+	tmp.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error writing temp file: %v\n", err)
+		os.Exit(1)
+	}
+
+	for {
+		if err := openEditor(tmp.Name()); err != nil {
+			fmt.Fprintf(os.Stderr, "error running editor: %v\n", err)
+			os.Exit(1)
+		}
+
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading temp file: %v\n", err)
+			os.Exit(1)
+		}
+
+		_, problems, err := config.ParseUser(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		if !hasErrors(problems) {
+			printProblems(problems)
+			if err := os.WriteFile(file.Path, data, 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "error writing config: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Saved %s\n", file.Path)
+			return
+		}
+
+		fmt.Fprintln(os.Stderr, "The config has problems:")
+		printProblems(problems)
+		if !confirm("Edit again? Otherwise the changes are discarded [Y/n] ") {
+			fmt.Fprintln(os.Stderr, "Config left unchanged")
+			os.Exit(1)
+		}
+	}
+}
+
+// runConfigCheck reports every problem in the config file at once and
+// exits non-zero if any of them is an error.
+func runConfigCheck(args []string) {
+	path := config.ConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Printf("No config file at %s; run 'adhanctl config init'\n", path)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "error reading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	_, problems, err := config.ParseUser(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	printProblems(problems)
+
+	if hasErrors(problems) {
+		os.Exit(1)
+	}
+	fmt.Printf("%s is valid\n", path)
+}
+
+func hasErrors(problems []config.Problem) bool {
+	for _, p := range problems {
+		if !p.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+func printProblems(problems []config.Problem) {
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "  %s\n", p)
+	}
+}
+
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may carry its own arguments, e.g. "code --wait".
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func confirm(prompt string) bool {
+	fmt.Fprint(os.Stderr, prompt)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(input))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+func readConfigFile() *config.File {
+	file, err := config.OpenFile(config.ConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	return file
+}
+
+func writeConfigFile(file *config.File) {
+	if err := file.Write(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/provider"
+)
+
+// runDoctor checks the setup and reports every problem it finds. With
+// --api it also probes the API endpoint for a compatible response.
+func runDoctor(args []string) {
+	probe := false
+	rest := make([]string, 0, len(args))
+	for _, a := range args {
+		if a == "--api" || a == "-api" {
+			probe = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+
+	ok := true
+	check := func(passed bool, label, format string, a ...any) {
+		mark := "✓"
+		if !passed {
+			mark = "✗"
+			ok = false
+		}
+		fmt.Printf("%s %-9s %s\n", mark, label, fmt.Sprintf(format, a...))
+	}
+
+	cfg, rest, err := loadConfig(rest)
+	if err != nil {
+		check(false, "Config", "%v", err)
+		os.Exit(1)
+	}
+	check(true, "Config", "%s", config.ConfigPath())
+
+	f := parseFlags(rest, cfg)
+	setupLogger(f.verbose)
+
+	located := validateLocation(f) == nil
+	switch {
+	case !located:
+		check(false, "Location", "none set; run 'adhanctl config init'")
+	case f.city != "":
+		check(true, "Location", "%s, %s (%.4f, %.4f)", f.city, f.country, f.latitude, f.longitude)
+	default:
+		check(true, "Location", "%.4f, %.4f", f.latitude, f.longitude)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.APITimeout+5*time.Second)
+	defer cancel()
+
+	if located {
+		p := newProvider(cfg)
+		params := buildParams(f)
+		if _, err := p.Day(ctx, params); err != nil {
+			check(false, "Source", "%s: %v", p.Name(), explainFetchError(cfg, params, err))
+		} else if cfg.Provider == "timetable" {
+			check(true, "Source", "timetable %s", timetablePath(cfg))
+		} else {
+			check(true, "Source", "%s", p.Name())
+		}
+	}
+
+	if probe {
+		probeAPI(ctx, cfg, f, located, check)
+	}
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// probeAPI fetches today's times once, bypassing the cache, and checks
+// the response has every field adhanctl reads.
+func probeAPI(ctx context.Context, cfg *config.Config, f *flags, located bool, check func(bool, string, string, ...any)) {
+	client := newClient(cfg)
+
+	fmt.Printf("\nAPI %s\n", client.BaseURL)
+	fmt.Printf("  User agent: %s\n", client.UserAgent)
+	if cfg.Proxy != "" {
+		fmt.Printf("  Proxy:      %s\n", cfg.Proxy)
+	}
+	fmt.Printf("  Timeout:    %s\n\n", cfg.APITimeout)
+
+	params := buildParams(f)
+	if !located {
+		// With no location configured, probe with the Kaaba's.
+		params = provider.Query{Latitude: geo.KaabaLatitude, Longitude: geo.KaabaLongitude, Method: f.method, Date: time.Now()}
+	}
+
+	start := time.Now()
+	problems, err := client.Probe(ctx, params)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		check(false, "Reach", "%v", explainFetchError(cfg, params, err))
+		return
+	}
+	check(true, "Reach", "answered in %s", elapsed)
+
+	if len(problems) > 0 {
+		check(false, "Schema", "the response isn't AlAdhan-compatible:")
+		for _, p := range problems {
+			fmt.Printf("    %s\n", p)
+		}
+		return
+	}
+	check(true, "Schema", "timings, dates and meta are all present")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
+	"github.com/zizouhuweidi/adhanctl/internal/notify"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+	"github.com/zizouhuweidi/adhanctl/internal/provider"
+)
+
+func runEvents(args []string) {
+	cfg, args, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	upcoming := "30d"
+	fasting := cfg.FastingReminders
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--upcoming" && i+1 < len(args):
+			upcoming = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--upcoming="):
+			upcoming = strings.TrimPrefix(args[i], "--upcoming=")
+		case args[i] == "--fasting":
+			fasting = true
+		case args[i] == "--no-fasting":
+			fasting = false
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+
+	f := parseFlags(rest, cfg)
+	setupLogger(f.verbose)
+
+	days, err := parseDays(upcoming)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --upcoming: %v\n", err)
+		os.Exit(1)
+	}
+
+	today := time.Now()
+	observances := hijri.Upcoming(today, days, locationHijriOffset(cfg, f), fasting)
+
+	fmt.Printf("\nIslamic calendar — next %d days:\n", days)
+	fmt.Println(strings.Repeat("-", 24))
+
+	if len(observances) == 0 {
+		fmt.Println("  Nothing notable")
+		return
+	}
+
+	for _, o := range observances {
+		when := o.Date.Format("Mon 02 Jan")
+		if o.Night {
+			when = o.Date.AddDate(0, 0, -1).Format("Mon 02 Jan") + " night"
+		}
+		fmt.Printf("  %-16s %-28s %s\n", when, o.Name, o.Hijri)
+	}
+}
+
+// parseDays accepts "60d", "8w", a plain number of days or a Go duration.
+func parseDays(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		return strconv.Atoi(strings.TrimSuffix(s, "d"))
+	case strings.HasSuffix(s, "w"):
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "w"))
+		return n * 7, err
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return int(d.Hours() / 24), nil
+}
+
+// locationHijriOffset aligns the arithmetical calendar with the API's Hijri
+// date for the configured location, falling back to no adjustment.
+func locationHijriOffset(cfg *config.Config, f *flags) int {
+	if validateLocation(f) != nil {
+		return 0
+	}
+	day, err := fetchDay(context.Background(), cfg, buildParams(f))
+	if err != nil {
+		return 0
+	}
+	return hijriOffset(day)
+}
+
+// hijriOffset is how far the provider's Hijri date for day is from the
+// arithmetical calendar's, taken after Fajr when the Hijri day has begun.
+func hijriOffset(day *provider.Day) int {
+	t := time.Now().In(day.Location())
+	for _, ev := range day.Events {
+		if ev.Name == "Fajr" {
+			t = ev.When
+		}
+	}
+	return hijri.Offset(day.Hijri, t)
+}
+
+// scheduleObservances announces tomorrow's notable days at this evening's
+// Maghrib, when the Islamic day already begins.
+func scheduleObservances(cfg *config.Config, sched *scheduler, day *provider.Day, events []prayer.Event) {
+	if !cfg.EventNotify {
+		return
+	}
+
+	var maghrib *prayer.Event
+	for i := range events {
+		if events[i].Name == "Maghrib" {
+			maghrib = &events[i]
+		}
+	}
+	if maghrib == nil {
+		return
+	}
+
+	tomorrow := maghrib.When.AddDate(0, 0, 1)
+	for _, o := range hijri.Upcoming(tomorrow, 1, hijriOffset(day), cfg.FastingReminders) {
+		title := "📅 Tomorrow: " + o.Name
+		body := o.Hijri.String()
+		if o.Night {
+			title = "🌙 Tonight: " + o.Name
+		} else if o.Kind == hijri.Fast && cfg.FastingReminders {
+			body += "\nConsider fasting tomorrow — remember to make your intention"
+		}
+		sched.schedule("observance:"+o.Name, maghrib.When, func() {
+			notify.Reminder(title, body)
+		})
+	}
+}
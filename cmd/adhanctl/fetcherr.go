@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/api"
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/waybar"
+)
+
+// fetchError is a fetch failure explained for the user. It still wraps the
+// original error, which -v logs in full.
+type fetchError struct {
+	msg string
+	err error
+}
+
+func (e *fetchError) Error() string { return e.msg }
+func (e *fetchError) Unwrap() error { return e.err }
+
+// explainFetchError turns an API failure into a message that says what
+// went wrong and what to do about it. Other errors pass through.
+func explainFetchError(cfg *config.Config, params api.TimingsParams, err error) error {
+	if err == nil {
+		return nil
+	}
+	slog.Debug("fetch failed", "error", err)
+
+	host := cfg.APIURL
+	if u, perr := url.Parse(cfg.APIURL); perr == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	var apiErr *api.Error
+	errors.As(err, &apiErr)
+
+	var msg string
+	switch {
+	case errors.Is(err, api.ErrInvalidLocation):
+		msg = fmt.Sprintf("%s doesn't know %s: check the city and country, or use --lat/--lon", host, describeQuery(params))
+		if params.City != "" {
+			msg += didYouMean(params.City)
+		}
+	case errors.Is(err, api.ErrRateLimited):
+		msg = fmt.Sprintf("%s is rate limiting requests; try again later", host)
+		if apiErr != nil && apiErr.RetryAfter > 0 {
+			msg = fmt.Sprintf("%s is rate limiting requests; try again in %s", host, apiErr.RetryAfter.Round(time.Second))
+		}
+	case errors.Is(err, api.ErrServer):
+		msg = fmt.Sprintf("%s is having problems (status %d); try again later", host, apiErr.Status)
+	case errors.Is(err, api.ErrNetwork):
+		msg = fmt.Sprintf("can't reach %s: check your connection", host)
+		if cfg.Proxy != "" {
+			msg += " and proxy " + cfg.Proxy
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		msg = fmt.Sprintf("%s didn't answer in time", host)
+	default:
+		return err
+	}
+	return &fetchError{msg: msg, err: err}
+}
+
+func describeQuery(params api.TimingsParams) string {
+	switch {
+	case params.Latitude != 0 && params.Longitude != 0:
+		return fmt.Sprintf("%.4f, %.4f", params.Latitude, params.Longitude)
+	case params.Address != "":
+		return fmt.Sprintf("%q", params.Address)
+	case params.Country != "":
+		return fmt.Sprintf("%q in %q", params.City, params.Country)
+	default:
+		return fmt.Sprintf("%q", params.City)
+	}
+}
+
+// waybarError is the module output for a failed fetch: a short label in
+// the bar and the full explanation in the tooltip.
+func waybarError(err error) waybar.Output {
+	text := "adhanctl: error"
+	switch {
+	case errors.Is(err, api.ErrInvalidLocation):
+		text = "adhanctl: unknown location"
+	case errors.Is(err, api.ErrRateLimited):
+		text = "adhanctl: rate limited"
+	case errors.Is(err, api.ErrServer):
+		text = "adhanctl: API down"
+	case errors.Is(err, api.ErrNetwork), errors.Is(err, context.DeadlineExceeded):
+		text = "adhanctl: offline"
+	}
+	return waybar.Output{Text: text, Tooltip: err.Error(), Class: []string{"error"}}
+}
@@ -5,30 +5,42 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/zizouhuweidi/adhanctl/internal/api"
+	"github.com/zizouhuweidi/adhanctl/internal/astro"
 	"github.com/zizouhuweidi/adhanctl/internal/cache"
 	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
 	"github.com/zizouhuweidi/adhanctl/internal/notify"
 	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+	"github.com/zizouhuweidi/adhanctl/internal/provider"
+	"github.com/zizouhuweidi/adhanctl/internal/travel"
 	"github.com/zizouhuweidi/adhanctl/internal/waybar"
 )
 
 var version = "dev"
 
 func main() {
-	if len(os.Args) < 2 {
-		runToday(os.Args[1:])
+	path, args := configFlag(os.Args[1:])
+	if path != "" {
+		config.SetPath(path)
+	}
+
+	if len(args) < 1 {
+		runToday(args)
 		return
 	}
 
-	cmd := os.Args[1]
-	args := os.Args[2:]
+	cmd := args[0]
+	args = args[1:]
 
 	switch cmd {
 	case "today":
@@ -41,8 +53,26 @@ func main() {
 		runServe(args)
 	case "waybar":
 		runWaybar(args)
+	case "events":
+		runEvents(args)
+	case "remind":
+		runRemind(args)
+	case "qibla":
+		runQibla(args)
+	case "profile":
+		runProfile(args)
+	case "world":
+		runWorld(args)
+	case "sun":
+		runSun(args)
+	case "moon":
+		runMoon(args)
 	case "config":
 		runConfig(args)
+	case "timetable":
+		runTimetable(args)
+	case "doctor":
+		runDoctor(args)
 	case "version", "-v", "--version":
 		fmt.Printf("adhanctl %s\n", version)
 	case "help", "-h", "--help":
@@ -66,7 +96,16 @@ Commands:
   notify      Send desktop notification for next prayer
   serve       Run background notifier daemon
   waybar      Output JSON for Waybar module
-  config      Manage configuration
+  events      List upcoming Islamic dates (--upcoming 60d)
+  remind      Manage Hijri-dated reminders (add, list, remove)
+  qibla       Show Qibla direction and distance (--rose for a compass)
+  sun         Show solar noon, twilight times and day length
+  moon        Show moon phase, next new moon and crescent visibility
+  profile     Manage location profiles (list, use)
+  world       Compare prayer times across cities (world Amman London)
+  config      Manage configuration (init, show, get, set, unset, edit, check)
+  timetable   Import a mosque timetable from CSV or ICS (timetable import FILE)
+  doctor      Check the setup (--api also probes the API endpoint)
   version     Show version
 
 Flags:
@@ -78,6 +117,10 @@ Flags:
   -s, --school int           Asr school: 0=Shafi, 1=Hanafi (default: 0)
       --ampm                 Use 12-hour format
       --ar                   Display Hijri in Arabic
+      --profile name         Use a named profile from the config
+      --config path          Use this config file instead of the default
+      --musafir              Apply travel prayer rules (qasr and combining)
+      --elevation metres     Correct Sunrise and Maghrib for elevation
   -v, --verbose              Enable debug logging
       --interval duration    Refresh interval for serve (default: 1m)
 
@@ -97,7 +140,12 @@ type flags struct {
 	ampm      bool
 	arabic    bool
 	verbose   bool
+	musafir   bool
 	interval  time.Duration
+	elevation float64
+
+	// timezone is known when the city was resolved from the gazetteer.
+	timezone string
 }
 
 func parseFlags(args []string, cfg *config.Config) *flags {
@@ -111,8 +159,37 @@ func parseFlags(args []string, cfg *config.Config) *flags {
 		ampm:      cfg.AmPm,
 		arabic:    cfg.Arabic,
 		interval:  cfg.Interval,
+		elevation: cfg.Elevation,
+	}
+
+	fs := newFlagSet(f)
+	_ = fs.Parse(args)
+
+	// Flags are the top config layer. Applying them through the config
+	// lets "--city Istanbul" replace the configured coordinates and
+	// country instead of being outranked by them.
+	var settings []config.Setting
+	fs.Visit(func(fl *flag.Flag) {
+		if key, ok := flagKeys[fl.Name]; ok {
+			settings = append(settings, config.Setting{Key: key, Value: fl.Value.String()})
+		}
+	})
+	layered, err := cfg.WithFlags(settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 	}
 
+	f.city, f.country = layered.City, layered.Country
+	f.latitude, f.longitude = layered.Latitude, layered.Longitude
+	f.method, f.school = layered.Method, layered.School
+	f.ampm, f.arabic = layered.AmPm, layered.Arabic
+	f.interval, f.elevation = layered.Interval, layered.Elevation
+	return f
+}
+
+// newFlagSet returns the common flags, bound to f with its values as the
+// defaults.
+func newFlagSet(f *flags) *flag.FlagSet {
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
@@ -133,10 +210,126 @@ func parseFlags(args []string, cfg *config.Config) *flags {
 	fs.BoolVar(&f.verbose, "verbose", false, "enable debug logging")
 	fs.BoolVar(&f.verbose, "v", false, "enable debug logging (shorthand)")
 	fs.DurationVar(&f.interval, "interval", f.interval, "refresh interval for serve")
+	fs.BoolVar(&f.musafir, "musafir", false, "apply travel (musafir) prayer rules")
+	fs.Float64Var(&f.elevation, "elevation", f.elevation, "elevation in metres")
+	return fs
+}
 
-	_ = fs.Parse(args)
+// flagKeys maps the flags that override a config key to that key.
+var flagKeys = map[string]string{
+	"city":      "city",
+	"c":         "city",
+	"country":   "country",
+	"C":         "country",
+	"latitude":  "latitude",
+	"lat":       "latitude",
+	"longitude": "longitude",
+	"lon":       "longitude",
+	"method":    "method",
+	"m":         "method",
+	"school":    "school",
+	"s":         "school",
+	"ampm":      "ampm",
+	"ar":        "arabic",
+	"interval":  "interval",
+	"elevation": "elevation",
+}
 
-	return f
+// loadConfig loads the config and applies the profile chosen with
+// --profile, or else the file's default profile. The returned args no
+// longer contain the profile flag.
+func loadConfig(args []string) (*config.Config, []string, error) {
+	name, rest := profileFlag(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, rest, err
+	}
+
+	if name == "" {
+		name = cfg.Profile
+	}
+	if name == "" {
+		return cfg, rest, nil
+	}
+
+	applied, err := cfg.WithProfile(name)
+	if err != nil {
+		return nil, rest, err
+	}
+	return applied, rest, nil
+}
+
+// configFlag removes --config PATH from args, wherever it appears, and
+// returns the path.
+func configFlag(args []string) (string, []string) {
+	path := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case (args[i] == "--config" || args[i] == "-config") && i+1 < len(args):
+			path = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--config="):
+			path = strings.TrimPrefix(args[i], "--config=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return path, rest
+}
+
+// profileFlag removes --profile NAME from args and returns the name.
+func profileFlag(args []string) (string, []string) {
+	name := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case (args[i] == "--profile" || args[i] == "-profile") && i+1 < len(args):
+			name = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--profile="):
+			name = strings.TrimPrefix(args[i], "--profile=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return name, rest
+}
+
+func newCache(cfg *config.Config) *cache.Cache {
+	return cache.New(time.Duration(cfg.CacheSecs) * time.Second).WithServer(cfg.APIURL).WithProfile(cfg.Active)
+}
+
+func newClient(cfg *config.Config) *api.Client {
+	opts := api.Options{
+		BaseURL:   cfg.APIURL,
+		Timeout:   cfg.APITimeout,
+		UserAgent: cfg.UserAgent,
+	}
+	// The config only accepts valid proxy URLs.
+	if u, err := url.Parse(cfg.Proxy); err == nil && cfg.Proxy != "" {
+		opts.Proxy = u
+	}
+	return api.NewClient(opts)
+}
+
+// newProvider returns the source of prayer times the config selects. Only
+// the API is cached; the other sources are local and quick to read. Dates
+// missing from a timetable come from the API.
+func newProvider(cfg *config.Config) provider.Provider {
+	aladhan := newAlAdhan(cfg)
+	switch cfg.Provider {
+	case "local":
+		return provider.NewLocal()
+	case "timetable":
+		return provider.NewTimetable(cfg.Timetable, aladhan)
+	}
+	return aladhan
+}
+
+func newAlAdhan(cfg *config.Config) provider.Provider {
+	return provider.NewCached(provider.NewAlAdhan(newClient(cfg)), newCache(cfg))
 }
 
 func setupLogger(verbose bool) {
@@ -149,8 +342,25 @@ func setupLogger(verbose bool) {
 	})))
 }
 
+// buildParams asks for today at f's location. The date is the location's
+// own, which can differ from the system's.
 func buildParams(f *flags) api.TimingsParams {
-	return buildParamsWithDate(f, time.Now())
+	return buildParamsWithDate(f, time.Now().In(f.location()))
+}
+
+// location returns the timezone of f's location, as far as it is known
+// before fetching: the gazetteer's zone, the zone at the coordinates, or
+// the system's for places only the API can resolve.
+func (f *flags) location() *time.Location {
+	if f.timezone != "" {
+		if loc, err := time.LoadLocation(f.timezone); err == nil {
+			return loc
+		}
+	}
+	if f.latitude != 0 || f.longitude != 0 {
+		return geo.LocationAt(f.latitude, f.longitude)
+	}
+	return time.Local
 }
 
 func buildParamsWithDate(f *flags, date time.Time) api.TimingsParams {
@@ -165,61 +375,162 @@ func buildParamsWithDate(f *flags, date time.Time) api.TimingsParams {
 	}
 }
 
-func fetchWithCache(ctx context.Context, cfg *config.Config, params api.TimingsParams) (*api.Response, error) {
-	client := api.NewClient()
-	c := cache.New(time.Duration(cfg.CacheSecs) * time.Second)
+func fetchDay(ctx context.Context, cfg *config.Config, params api.TimingsParams) (*provider.Day, error) {
+	day, err := newProvider(cfg).Day(ctx, params)
+	if err != nil {
+		return nil, explainFetchError(cfg, params, err)
+	}
+	return day, nil
+}
 
-	if resp, ok := c.Get(params); ok {
-		return resp, nil
+// fetchPlaceDay fetches a day for any place rather than the configured
+// location, so a timetable, which only holds the mosque's own times, is
+// never used. The local provider is tried first when selected, with the
+// API for places it can't resolve offline.
+func fetchPlaceDay(ctx context.Context, cfg *config.Config, params api.TimingsParams) (*provider.Day, error) {
+	if cfg.Provider == "local" {
+		day, err := provider.NewLocal().Day(ctx, params)
+		if err == nil {
+			return day, nil
+		}
+		slog.Debug("local provider failed, using the API", "error", err)
 	}
+	day, err := newAlAdhan(cfg).Day(ctx, params)
+	if err != nil {
+		return nil, explainFetchError(cfg, params, err)
+	}
+	return day, nil
+}
+
+// findNextEvent returns day's events and the next of them, or tomorrow's
+// first prayer once today's are over.
+func findNextEvent(ctx context.Context, cfg *config.Config, f *flags, day *provider.Day) (*prayer.Event, []prayer.Event) {
+	events := parseEvents(cfg, f, day)
+	now := time.Now().In(day.Location())
 
-	resp, err := client.FetchTimings(ctx, params)
+	next := prayer.NextEventAfter(events, now)
+	if next != nil {
+		return next, events
+	}
+
+	tomorrowParams := buildParamsWithDate(f, now.AddDate(0, 0, 1))
+	tomorrow, err := fetchDay(ctx, cfg, tomorrowParams)
 	if err != nil {
-		return nil, err
+		return nil, events
 	}
 
-	_ = c.Set(params, resp)
-	return resp, nil
+	tomorrowEvents := parseEvents(cfg, f, tomorrow)
+	return prayer.NextEventAfter(tomorrowEvents, now), events
 }
 
-func findNextEvent(ctx context.Context, cfg *config.Config, f *flags, loc *time.Location) (*prayer.Event, []prayer.Event, *api.Response, error) {
-	params := buildParams(f)
-	resp, err := fetchWithCache(ctx, cfg, params)
-	if err != nil {
-		return nil, nil, nil, err
+// parseEvents returns day's events as this user prays them, after the
+// elevation correction and Jumu'ah.
+func parseEvents(cfg *config.Config, f *flags, day *provider.Day) []prayer.Event {
+	events := day.Events
+
+	// Timetables are published for the masjid as they are.
+	if f.elevation > 0 && cfg.Provider != "timetable" {
+		lat, lon := f.latitude, f.longitude
+		if lat == 0 && lon == 0 {
+			lat, lon = day.Latitude, day.Longitude
+		}
+		events = prayer.ApplyElevation(events, lat, lon, f.elevation)
 	}
 
-	events := prayer.ParseTimes(resp, loc)
-	now := time.Now().In(loc)
+	// Jumu'ah isn't due from a traveller, who prays Dhuhr instead, and
+	// the home masjid's times don't apply away from home.
+	if isMusafir(cfg, f, day) {
+		return events
+	}
 
-	next := prayer.NextEventAfter(events, now)
-	if next != nil {
-		return next, events, resp, nil
+	jumuah, err := prayer.ApplyJumuah(events, cfg.JumuahKhutbah, cfg.JumuahIqamah)
+	if err != nil {
+		slog.Warn("ignoring jumuah times", "error", err)
 	}
+	return jumuah
+}
 
-	tomorrowParams := buildParamsWithDate(f, time.Now().Add(24*time.Hour))
-	tomorrowResp, err := fetchWithCache(ctx, cfg, tomorrowParams)
+func ishaEnd(cfg *config.Config) prayer.IshaEnd {
+	end, err := prayer.ParseIshaEnd(cfg.IshaEnd)
 	if err != nil {
-		return nil, events, resp, nil
+		slog.Warn("ignoring isha_end", "error", err)
+		return prayer.IshaEndMidnight
 	}
+	return end
+}
 
-	tomorrowEvents := prayer.ParseTimes(tomorrowResp, loc)
-	tomorrowNext := prayer.NextEventAfter(tomorrowEvents, time.Now().In(loc))
-	return tomorrowNext, events, resp, nil
+func makruhMargins(cfg *config.Config) prayer.MakruhMargins {
+	return prayer.MakruhMargins{
+		Sunrise: cfg.MakruhSunrise,
+		Zawal:   cfg.MakruhZawal,
+		Sunset:  cfg.MakruhSunset,
+	}
 }
 
+func isRamadan(cfg *config.Config, day *provider.Day) bool {
+	switch cfg.Ramadan {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	return day.Hijri.Month == hijri.Ramadan
+}
+
+func fastingDay(cfg *config.Config, day *provider.Day, events []prayer.Event) *prayer.FastingDay {
+	if !isRamadan(cfg, day) {
+		return nil
+	}
+	return prayer.Fasting(day.Imsak, events)
+}
+
+// hijriString is day's Hijri date as the output shows it.
+func hijriString(day *provider.Day, arabic bool) string {
+	return prayer.HijriString(day.Hijri, day.Date.Weekday(), arabic)
+}
+
+// validateLocation checks that f has a usable location, resolving known
+// cities to coordinates offline through the gazetteer.
 func validateLocation(f *flags) error {
 	if f.latitude != 0 && f.longitude != 0 {
 		return nil
 	}
+
+	if f.city != "" {
+		if city, ok := geo.LookupCity(f.city, f.country); ok {
+			slog.Debug("resolved city offline", "city", city, "lat", city.Latitude, "lon", city.Longitude)
+			f.latitude, f.longitude = city.Latitude, city.Longitude
+			f.timezone = city.Timezone
+			if f.country == "" {
+				f.country = city.Country
+			}
+			return nil
+		}
+	}
+
 	if f.city != "" && f.country != "" {
 		return nil
 	}
+	if f.city != "" {
+		return fmt.Errorf("unknown city %q%s: add --country or use --lat/--lon", f.city, didYouMean(f.city))
+	}
 	return fmt.Errorf("no location provided: use --city/--country or --lat/--lon, or run 'adhanctl config init'")
 }
 
+func didYouMean(city string) string {
+	suggestions := geo.SuggestCities(city, 3)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	names := make([]string, len(suggestions))
+	for i, c := range suggestions {
+		names[i] = c.String()
+	}
+	return " (did you mean " + strings.Join(names, ", ") + "?)"
+}
+
 func runToday(args []string) {
-	cfg, err := config.Load()
+	cfg, args, err := loadConfig(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
 		os.Exit(1)
@@ -235,32 +546,61 @@ func runToday(args []string) {
 
 	ctx := context.Background()
 	params := buildParams(f)
-	resp, err := fetchWithCache(ctx, cfg, params)
+	day, err := fetchDay(ctx, cfg, params)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error fetching timings: %v\n", err)
 		os.Exit(1)
 	}
 
-	loc := prayer.TimezoneFromResp(resp)
-	events := prayer.ParseTimes(resp, loc)
-	now := time.Now().In(loc)
+	events := parseEvents(cfg, f, day)
+	now := time.Now().In(day.Location())
 
-	hijri := prayer.HijriString(resp, f.arabic)
-	fmt.Printf("\n📅 %s\n\n", hijri)
+	fmt.Printf("\n📅 %s\n\n", hijriString(day, f.arabic))
+
+	musafir := isMusafir(cfg, f, day)
 
 	fmt.Println("Today's Prayer Schedule:")
 	fmt.Println(strings.Repeat("-", 24))
 
-	for _, name := range prayer.StandardOrder {
-		for _, e := range events {
-			if e.Name == name {
-				marker := ""
-				if now.After(e.When) {
-					marker = " ✓"
-				}
-				fmt.Printf("  %-8s %s%s\n", name, prayer.FormatTime(e.When, f.ampm), marker)
-				break
+	for _, e := range events {
+		marker := ""
+		if now.After(e.When) {
+			marker = " ✓"
+		}
+		iqamah := ""
+		if !e.Khutbah.IsZero() {
+			iqamah = fmt.Sprintf(" (khutbah %s)", prayer.FormatTime(e.Khutbah, f.ampm))
+		}
+		if !e.Iqamah.IsZero() {
+			iqamah += fmt.Sprintf(" (iqamah %s)", prayer.FormatTime(e.Iqamah, f.ampm))
+		}
+		fmt.Printf("  %-8s %s%s%s%s\n", e.Name, prayer.FormatTime(e.When, f.ampm), iqamah,
+			qasrMarker(musafir, e.Name), marker)
+	}
+
+	if musafir {
+		fmt.Println("\n🧳 Musafir: Dhuhr, Asr and Isha shortened to two rak'ahs")
+		for _, c := range combinations(cfg, musafir, events) {
+			fmt.Printf("  %-15s %s\n", c.Name(), c.Spans(f.ampm))
+		}
+	}
+
+	if fast := fastingDay(cfg, day, events); fast != nil {
+		fmt.Printf("\n🌙 Ramadan: suhoor until %s (%s), iftar at %s\n",
+			prayer.FormatTime(fast.Suhoor, f.ampm), fast.SuhoorName, prayer.FormatTime(fast.Iftar, f.ampm))
+	}
+
+	if makruh := prayer.MakruhWindows(events, makruhMargins(cfg)); len(makruh) > 0 {
+		fmt.Println("\nDisliked for voluntary prayer:")
+		for _, w := range makruh {
+			marker := ""
+			if now.After(w.End) {
+				marker = " ✓"
+			} else if !now.Before(w.Start) {
+				marker = " ←"
 			}
+			fmt.Printf("  %-8s %s–%s%s\n", w.Name,
+				prayer.FormatTime(w.Start, f.ampm), prayer.FormatTime(w.End, f.ampm), marker)
 		}
 	}
 
@@ -272,7 +612,7 @@ func runToday(args []string) {
 }
 
 func runNext(args []string) {
-	cfg, err := config.Load()
+	cfg, args, err := loadConfig(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
 		os.Exit(1)
@@ -288,17 +628,18 @@ func runNext(args []string) {
 
 	ctx := context.Background()
 	params := buildParams(f)
-	resp, err := fetchWithCache(ctx, cfg, params)
+	day, err := fetchDay(ctx, cfg, params)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error fetching timings: %v\n", err)
 		os.Exit(1)
 	}
 
-	loc := prayer.TimezoneFromResp(resp)
-	next, _, _, err := findNextEvent(ctx, cfg, f, loc)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error finding next prayer: %v\n", err)
-		os.Exit(1)
+	next, events := findNextEvent(ctx, cfg, f, day)
+	now := time.Now().In(day.Location())
+
+	if w := prayer.CurrentWindow(events, now, ishaEnd(cfg)); w != nil {
+		fmt.Printf("⏳ %s ends in %s (%s at %s)\n", w.Name,
+			prayer.HumanDuration(w.Remaining(now)), w.EndName, prayer.FormatTime(w.End, f.ampm))
 	}
 
 	if next == nil {
@@ -306,20 +647,24 @@ func runNext(args []string) {
 		os.Exit(0)
 	}
 
-	now := time.Now().In(loc)
 	rem := prayer.HumanDuration(next.When.Sub(now))
 	timeStr := prayer.FormatTime(next.When, f.ampm)
 
 	fmt.Printf("🕌 %s at %s (%s)\n", next.Name, timeStr, rem)
 
-	hijri := prayer.HijriString(resp, f.arabic)
-	if hijri != "" {
+	if fast := fastingDay(cfg, day, events); fast != nil {
+		if label, left := fast.Countdown(now); label != "" {
+			fmt.Printf("🌙 %s in %s\n", label, prayer.HumanDuration(left))
+		}
+	}
+
+	if hijri := hijriString(day, f.arabic); hijri != "" {
 		fmt.Printf("📅 %s\n", hijri)
 	}
 }
 
 func runNotify(args []string) {
-	cfg, err := config.Load()
+	cfg, args, err := loadConfig(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
 		os.Exit(1)
@@ -335,38 +680,31 @@ func runNotify(args []string) {
 
 	ctx := context.Background()
 	params := buildParams(f)
-	resp, err := fetchWithCache(ctx, cfg, params)
+	day, err := fetchDay(ctx, cfg, params)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error fetching timings: %v\n", err)
 		os.Exit(1)
 	}
 
-	loc := prayer.TimezoneFromResp(resp)
-	next, _, _, err := findNextEvent(ctx, cfg, f, loc)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error finding next prayer: %v\n", err)
-		os.Exit(1)
-	}
-
+	next, _ := findNextEvent(ctx, cfg, f, day)
 	if next == nil {
 		fmt.Fprintln(os.Stderr, "no upcoming prayer found")
 		os.Exit(1)
 	}
 
-	hijri := prayer.HijriString(resp, f.arabic)
-	notify.Prayer(*next, hijri)
+	notify.Prayer(*next, hijriString(day, f.arabic))
 
 	fmt.Printf("Sent notification: %s at %s\n", next.Name, prayer.FormatTime(next.When, f.ampm))
 }
 
 func runServe(args []string) {
-	cfg, err := config.Load()
+	cfg, rest, err := loadConfig(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	f := parseFlags(args, cfg)
+	f := parseFlags(rest, cfg)
 	setupLogger(f.verbose)
 
 	if err := validateLocation(f); err != nil {
@@ -381,30 +719,33 @@ func runServe(args []string) {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	client := api.NewClient()
-	c := cache.New(time.Duration(cfg.CacheSecs) * time.Second)
+	p := newProvider(cfg)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	sched := newScheduler()
+	defer sched.stop()
+
+	// here is where the scheduled times are for, as the source resolved it.
+	var here position
+
 	scheduleEvents := func() {
 		params := buildParams(f)
 
-		var resp *api.Response
-		if cached, ok := c.Get(params); ok {
-			resp = cached
-		} else {
-			var err error
-			resp, err = client.FetchTimings(ctx, params)
-			if err != nil {
-				slog.Debug("fetch error", "error", err)
-				return
-			}
-			_ = c.Set(params, resp)
+		day, err := p.Day(ctx, params)
+		if err != nil {
+			slog.Warn("can't fetch prayer times", "source", p.Name(), "error", explainFetchError(cfg, params, err))
+			return
 		}
 
-		loc := prayer.TimezoneFromResp(resp)
-		events := prayer.ParseTimes(resp, loc)
+		loc := day.Location()
+		here = position{
+			latitude:  day.Latitude,
+			longitude: day.Longitude,
+			timezone:  loc.String(),
+		}
+		events := parseEvents(cfg, f, day)
 
 		if len(events) == 0 {
 			slog.Debug("no prayer times parsed")
@@ -416,17 +757,101 @@ func runServe(args []string) {
 
 		slog.Debug("scheduling events", "count", len(upcoming))
 
-		hijri := prayer.HijriString(resp, f.arabic)
+		hijri := hijriString(day, f.arabic)
+
+		// When combining, each pair gets one notification at the first
+		// prayer, and the first prayer's window no longer matters. The
+		// second's window still does, as it ends the combined time.
+		combined := make(map[string]bool)
+		first := make(map[string]bool)
+		for _, c := range combinations(cfg, isMusafir(cfg, f, day), events) {
+			combined[c.First.Name] = true
+			combined[c.Second.Name] = true
+			first[c.First.Name] = true
+			sched.schedule("combined:"+c.Name(), c.First.When, func() {
+				notify.Combined(c, hijri)
+			})
+		}
 
 		for _, ev := range upcoming {
-			if ev.When.Before(now) {
+			if combined[ev.Name] {
 				continue
 			}
-			go scheduleNotification(ev, hijri)
+			sched.schedule("prayer:"+ev.Name, ev.When, func() {
+				notify.Prayer(ev, hijri)
+			})
+		}
+
+		scheduleWindowWarnings(cfg, sched, events, first)
+		scheduleJumuah(cfg, sched, events)
+
+		if fast := fastingDay(cfg, day, events); fast != nil {
+			scheduleRamadan(cfg, sched, fast, events)
 		}
+
+		scheduleObservances(cfg, sched, day, events)
+		scheduleReminders(sched, day, events)
+	}
+
+	var trav *traveller
+	if cfg.Travel {
+		trav = newTraveller(cfg, f)
+	}
+
+	followTravel := func() {
+		if trav == nil || here.timezone == "" {
+			return
+		}
+		place, moved := trav.follow(ctx, f, here)
+		if !moved {
+			return
+		}
+		sched.stop()
+		notify.Reminder("📍 Location changed", fmt.Sprintf("Now using %s times", place))
+		scheduleEvents()
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	watcher := newConfigWatcher()
+	watchTicker := time.NewTicker(configPollInterval)
+	defer watchTicker.Stop()
+
+	// reload swaps in the new settings only once they load and validate,
+	// then reschedules everything from them. A travel move carries over
+	// while travel mode reads the same hints.
+	reload := func(reason string) {
+		newCfg, newFlags, err := reloadConfig(args)
+		if err != nil {
+			slog.Warn("keeping current config", "reason", reason, "error", err)
+			notify.Reminder("⚠️ Config not reloaded", err.Error())
+			return
+		}
+
+		slog.Info("reloaded config", "reason", reason)
+		cfg, f = newCfg, newFlags
+		p = newProvider(cfg)
+		interval = max(f.interval, 10*time.Second)
+		ticker.Reset(interval)
+
+		switch {
+		case !cfg.Travel:
+			trav = nil
+		case trav != nil && trav.sameSource(cfg):
+			trav.reapply(f)
+		default:
+			trav = newTraveller(cfg, f)
+		}
+
+		sched.stop()
+		scheduleEvents()
+		followTravel()
 	}
 
 	scheduleEvents()
+	followTravel()
 
 	for {
 		select {
@@ -434,24 +859,134 @@ func runServe(args []string) {
 			slog.Info("shutting down")
 			return
 		case <-ticker.C:
+			followTravel()
 			scheduleEvents()
+		case <-hup:
+			reload("SIGHUP")
+		case <-watchTicker.C:
+			if watcher.changed() {
+				reload("config file changed")
+			}
+		}
+	}
+}
+
+// scheduleWindowWarnings warns before each prayer's window ends, except
+// for the prayers in skip. The warning is copied now, as a reload may
+// replace cfg before the timers fire.
+func scheduleWindowWarnings(cfg *config.Config, sched *scheduler, events []prayer.Event, skip map[string]bool) {
+	warn := cfg.WindowWarn
+	if warn <= 0 {
+		return
+	}
+	for _, w := range prayer.Windows(events, ishaEnd(cfg)) {
+		if skip[w.Name] {
+			continue
 		}
+		sched.schedule("window:"+w.Name, w.End.Add(-warn), func() {
+			notify.WindowEnding(w, warn)
+		})
 	}
 }
 
-func scheduleNotification(ev prayer.Event, hijri string) {
-	d := time.Until(ev.When)
-	slog.Debug("scheduled notification", "prayer", ev.Name, "in", d)
+func scheduleJumuah(cfg *config.Config, sched *scheduler, events []prayer.Event) {
+	for _, ev := range events {
+		if !prayer.IsDhuhr(ev.Name) || ev.When.Weekday() != time.Friday {
+			continue
+		}
 
-	timer := time.NewTimer(d)
-	defer timer.Stop()
+		if ev.Name == prayer.Jumuah && cfg.JumuahReminder > 0 {
+			at, what := ev.When, ev.Name
+			if !ev.Khutbah.IsZero() {
+				at, what = ev.Khutbah, "khutbah"
+			}
+			sched.schedule("jumuah", at.Add(-cfg.JumuahReminder), func() {
+				notify.Reminder("🕌 Jumu'ah", fmt.Sprintf("Time to leave for the masjid — %s at %s",
+					what, at.Format(time.Kitchen)))
+			})
+		}
 
-	<-timer.C
-	notify.Prayer(ev, hijri)
+		if cfg.KahfReminder != "" {
+			at, err := prayer.ClockOn(ev.When, cfg.KahfReminder)
+			if err != nil {
+				slog.Warn("ignoring kahf_reminder", "error", err)
+				continue
+			}
+			sched.schedule("kahf", at, func() {
+				notify.Reminder("📖 Surah al-Kahf", "It's Friday — remember to recite Surah al-Kahf")
+			})
+		}
+	}
+}
+
+func scheduleRamadan(cfg *config.Config, sched *scheduler, fast *prayer.FastingDay, events []prayer.Event) {
+	for _, d := range cfg.SuhoorWarnings {
+		sched.schedule("suhoor:"+d.String(), fast.Suhoor.Add(-d), func() {
+			notify.Reminder("🌙 Suhoor", fmt.Sprintf("Suhoor ends in %s (%s at %s)",
+				prayer.HumanDuration(d), fast.SuhoorName, fast.Suhoor.Format(time.Kitchen)))
+		})
+	}
+
+	if cfg.Taraweeh <= 0 {
+		return
+	}
+	for _, ev := range events {
+		if ev.Name == "Isha" {
+			sched.schedule("taraweeh", ev.When.Add(cfg.Taraweeh), func() {
+				notify.Reminder("🌙 Taraweeh", "Time for Taraweeh prayer")
+			})
+		}
+	}
+}
+
+type scheduler struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{timers: make(map[string]*time.Timer)}
+}
+
+// schedule runs fn at the given time unless the same key was already
+// scheduled for it, so refreshing serve every interval doesn't duplicate
+// notifications.
+func (s *scheduler) schedule(name string, at time.Time, fn func()) {
+	d := time.Until(at)
+	if d <= 0 {
+		return
+	}
+
+	key := fmt.Sprintf("%s@%d", name, at.Unix())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.timers[key]; ok {
+		return
+	}
+
+	slog.Debug("scheduled notification", "name", name, "in", d)
+	s.timers[key] = time.AfterFunc(d, func() {
+		fn()
+		s.mu.Lock()
+		delete(s.timers, key)
+		s.mu.Unlock()
+	})
+}
+
+func (s *scheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, t := range s.timers {
+		t.Stop()
+		delete(s.timers, key)
+	}
 }
 
 func runWaybar(args []string) {
-	cfg, err := config.Load()
+	cfg, args, err := loadConfig(args)
 	if err != nil {
 		waybar.Print(waybar.Output{Text: "adhanctl: config error", Tooltip: err.Error()})
 		os.Exit(0)
@@ -477,26 +1012,34 @@ func runWaybar(args []string) {
 
 	ctx := context.Background()
 	params := buildParams(f)
-	resp, err := fetchWithCache(ctx, cfg, params)
-	if err != nil {
-		waybar.Print(waybar.Output{Text: "adhanctl: error", Tooltip: err.Error()})
-		os.Exit(0)
-	}
-
-	loc := prayer.TimezoneFromResp(resp)
-	next, events, resp, err := findNextEvent(ctx, cfg, f, loc)
+	day, err := fetchDay(ctx, cfg, params)
 	if err != nil {
-		waybar.Print(waybar.Output{Text: "adhanctl: error", Tooltip: err.Error()})
+		waybar.Print(waybarError(err))
 		os.Exit(0)
 	}
 
-	out := waybar.Build(resp, next, events, f.ampm, f.arabic, short)
+	next, events := findNextEvent(ctx, cfg, f, day)
+	now := time.Now().In(day.Location())
+	musafir := isMusafir(cfg, f, day)
+	out := waybar.Build(day, next, events, waybar.Options{
+		AmPm:       f.ampm,
+		Arabic:     f.arabic,
+		Short:      short,
+		Window:     prayer.CurrentWindow(events, now, ishaEnd(cfg)),
+		WindowWarn: cfg.WindowWarn,
+		Makruh:     prayer.ActiveWindow(prayer.MakruhWindows(events, makruhMargins(cfg)), now),
+		Fasting:    fastingDay(cfg, day, events),
+		Qibla:      cfg.WaybarQibla,
+
+		Musafir:      musafir,
+		Combinations: combinations(cfg, musafir, events),
+	})
 	waybar.Print(out)
 }
 
 func runConfig(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "config subcommand required: init, show")
+		fmt.Fprintln(os.Stderr, "config subcommand required: init, show, get, set, unset, edit, check")
 		os.Exit(1)
 	}
 
@@ -508,32 +1051,139 @@ func runConfig(args []string) {
 		runConfigInit(subArgs)
 	case "show":
 		runConfigShow(subArgs)
+	case "get":
+		runConfigGet(subArgs)
+	case "set":
+		runConfigSet(subArgs)
+	case "unset":
+		runConfigUnset(subArgs)
+	case "edit":
+		runConfigEdit(subArgs)
+	case "check":
+		runConfigCheck(subArgs)
 	default:
 		fmt.Fprintf(os.Stderr, "unknown config subcommand: %s\n", sub)
 		os.Exit(1)
 	}
 }
 
+// checkLocation fetches today's times for a new config to make sure its
+// source accepts the location.
+func checkLocation(cfg *config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	f := parseFlags(nil, cfg)
+	if err := validateLocation(f); err != nil {
+		return err
+	}
+
+	params := buildParams(f)
+	_, err := newProvider(cfg).Day(ctx, params)
+	return explainFetchError(cfg, params, err)
+}
+
+// printConfigSources lists every key with the layer that set it. Flags are
+// per command, so they aren't shown here.
+func printConfigSources(cfg *config.Config) {
+	fmt.Printf("System config: %s\n", config.SystemConfigPath())
+	fmt.Printf("User config:   %s\n\n", config.ConfigPath())
+
+	profileSource := cfg.Source("profile")
+	if cfg.Active != cfg.Profile {
+		profileSource = "flag --profile"
+	}
+	printSource("profile", cfg.Active, profileSource)
+
+	for _, st := range cfg.Settings() {
+		printSource(st.Key, st.Value, cfg.Source(st.Key))
+	}
+}
+
+func printSource(key, value, source string) {
+	if value == "" {
+		value = "-"
+	}
+	fmt.Printf("  %-18s %-24s %s\n", key, value, source)
+}
+
 func runConfigInit(args []string) {
 	fs := flag.NewFlagSet("config init", flag.ContinueOnError)
 	verbose := fs.Bool("v", false, "verbose")
-	_ = fs.Parse(args)
+	nonInteractive := fs.Bool("non-interactive", false, "don't prompt; use flags, suggestions and defaults")
+	fs.BoolVar(nonInteractive, "y", false, "non-interactive (shorthand)")
+	noValidate := fs.Bool("no-validate", false, "skip the test fetch for the location")
+	fs.String("city", "", "city name")
+	fs.String("country", "", "country name")
+	fs.String("lat", "", "latitude")
+	fs.String("lon", "", "longitude")
+	fs.String("method", "", "calculation method")
+	fs.String("school", "", "asr school")
+	fs.Bool("ampm", false, "use 12-hour format")
+	fs.Bool("ar", false, "display Hijri in Arabic")
+	fs.Bool("short", false, "short Waybar output")
+	fs.String("cache-secs", "", "cache TTL in seconds")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
 
 	setupLogger(*verbose)
 
+	// Only flags actually given become answers, so the rest are still
+	// asked for or defaulted.
+	keys := map[string]string{
+		"city": "city", "country": "country", "lat": "latitude", "lon": "longitude",
+		"method": "method", "school": "school", "ampm": "ampm", "ar": "arabic",
+		"short": "short", "cache-secs": "cache_secs",
+	}
+	answers := make(map[string]string)
+	fs.Visit(func(fl *flag.Flag) {
+		if key, ok := keys[fl.Name]; ok {
+			answers[key] = fl.Value.String()
+		}
+	})
+
+	opts := config.InitOptions{
+		Answers:        answers,
+		NonInteractive: *nonInteractive,
+	}
+	if !*noValidate {
+		opts.Validate = checkLocation
+	}
+
 	interactor := &config.StdioInteractor{}
-	cfg, err := config.RunConfigInit(interactor)
+	cfg, err := config.RunConfigInit(interactor, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if err := cfg.Save(); err != nil {
+	// Keep profiles from the old file even if it has errors, since the
+	// rest of it is being replaced.
+	if data, err := os.ReadFile(config.ConfigPath()); err == nil {
+		existing, _ := config.Parse(data)
+		cfg.Profile = existing.Profile
+		for _, name := range existing.ProfileNames() {
+			cfg.AddProfile(name, existing.Profiles[name])
+		}
+	}
+
+	// Only what differs from the defaults and the system config is written,
+	// along with what was given as a flag, so later changes to the
+	// defaults still apply.
+	base, err := config.Base()
+	if err != nil {
+		base = config.Default()
+	}
+	if err := cfg.Save(base, answers); err != nil {
 		fmt.Fprintf(os.Stderr, "error saving config: %v\n", err)
 		os.Exit(1)
 	}
 
 	fmt.Printf("\nConfiguration saved to %s\n", config.ConfigPath())
+	if *nonInteractive {
+		return
+	}
 	fmt.Println("\nYou can now run:")
 	fmt.Println("  adhanctl today  - View today's schedule")
 	fmt.Println("  adhanctl serve  - Run background notifier")
@@ -541,15 +1191,26 @@ func runConfigInit(args []string) {
 }
 
 func runConfigShow(args []string) {
-	cfg, err := config.Load()
+	cfg, args, err := loadConfig(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
+	for _, a := range args {
+		if a == "--sources" || a == "-sources" {
+			printConfigSources(cfg)
+			return
+		}
+	}
+
 	fmt.Printf("Config file: %s\n\n", config.ConfigPath())
 	fmt.Println("Current configuration:")
 
+	if cfg.Active != "" {
+		fmt.Printf("  Profile:   %s\n", cfg.Active)
+	}
+
 	if cfg.City != "" {
 		fmt.Printf("  City:      %s\n", cfg.City)
 	}
@@ -562,11 +1223,62 @@ func runConfigShow(args []string) {
 	if cfg.Longitude != 0 {
 		fmt.Printf("  Longitude: %.6f\n", cfg.Longitude)
 	}
+	if cfg.Elevation > 0 {
+		fmt.Printf("  Elevation: %gm (horizon dip %.2f°)\n", cfg.Elevation, astro.HorizonDip(cfg.Elevation))
+	}
 	fmt.Printf("  Method:    %d (%s)\n", cfg.Method, config.CalculationMethods[cfg.Method])
 	fmt.Printf("  School:    %d (%s)\n", cfg.School, config.Schools[cfg.School])
+	if cfg.Provider == "timetable" {
+		fmt.Printf("  Source:    timetable %s\n", timetablePath(cfg))
+	} else {
+		fmt.Printf("  Source:    %s\n", cfg.Provider)
+	}
+	if cfg.Provider != "local" {
+		fmt.Printf("  API:       %s (timeout %s)\n", cfg.APIURL, cfg.APITimeout)
+	}
+	if cfg.Proxy != "" {
+		fmt.Printf("  Proxy:     %s\n", cfg.Proxy)
+	}
 	fmt.Printf("  12-hour:   %t\n", cfg.AmPm)
 	fmt.Printf("  Arabic:    %t\n", cfg.Arabic)
 	fmt.Printf("  Short:     %t\n", cfg.Short)
 	fmt.Printf("  Cache:     %d seconds\n", cfg.CacheSecs)
 	fmt.Printf("  Interval:  %s\n", cfg.Interval)
+	fmt.Printf("  Isha end:  %s\n", cfg.IshaEnd)
+	fmt.Printf("  Warn:      %s before a prayer window ends\n", cfg.WindowWarn)
+	fmt.Printf("  Makruh:    sunrise +%s, zawal -%s, sunset -%s\n",
+		cfg.MakruhSunrise, cfg.MakruhZawal, cfg.MakruhSunset)
+	if cfg.JumuahKhutbah != "" || cfg.JumuahIqamah != "" {
+		fmt.Printf("  Jumu'ah:   khutbah %s, iqamah %s\n", cfg.JumuahKhutbah, cfg.JumuahIqamah)
+	}
+	if cfg.JumuahReminder > 0 {
+		fmt.Printf("  Reminder:  %s before Jumu'ah\n", cfg.JumuahReminder)
+	}
+	if cfg.KahfReminder != "" {
+		fmt.Printf("  Al-Kahf:   %s on Fridays\n", cfg.KahfReminder)
+	}
+	fmt.Printf("  Ramadan:   %s (suhoor warnings %v)\n", cfg.Ramadan, cfg.SuhoorWarnings)
+	if cfg.Taraweeh > 0 {
+		fmt.Printf("  Taraweeh:  %s after Isha\n", cfg.Taraweeh)
+	}
+	fmt.Printf("  Events:    %t (voluntary fasts %t)\n", cfg.EventNotify, cfg.FastingReminders)
+	fmt.Printf("  Qibla:     %t in Waybar tooltip\n", cfg.WaybarQibla)
+	if cfg.Travel {
+		fmt.Printf("  Travel:    following timezone, %s", travel.NewDetector(cfg.LocationFile, cfg.GPSD).LocationFile)
+		if cfg.GPSD != "" {
+			fmt.Printf(", gpsd at %s", cfg.GPSD)
+		}
+		fmt.Println()
+	} else {
+		fmt.Println("  Travel:    off")
+	}
+	musafir := cfg.Musafir
+	if musafir == "auto" {
+		home := "top-level settings"
+		if cfg.HomeProfile != "" {
+			home = "profile " + cfg.HomeProfile
+		}
+		musafir = fmt.Sprintf("auto, %g km from %s", cfg.MusafirDistance, home)
+	}
+	fmt.Printf("  Musafir:   %s (combine %t)\n", musafir, cfg.Combine)
 }
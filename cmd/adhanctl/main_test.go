@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildParamsUsesLocationDate(t *testing.T) {
+	host, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip(err)
+	}
+	saved := time.Local
+	time.Local = host
+	t.Cleanup(func() { time.Local = saved })
+
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		name string
+		f    *flags
+	}{
+		{name: "gazetteer zone", f: &flags{latitude: -36.8485, longitude: 174.7633, timezone: "Pacific/Auckland"}},
+		{name: "coordinates only", f: &flags{latitude: -36.8485, longitude: 174.7633}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := buildParams(tt.f)
+			want := time.Now().In(auckland).Format("2006-01-02")
+			if got := params.Date.Format("2006-01-02"); got != want {
+				t.Errorf("got date %s, want Auckland's %s", got, want)
+			}
+			if got := params.Date.Location().String(); got != "Pacific/Auckland" {
+				t.Errorf("got zone %s, want Pacific/Auckland", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+	"github.com/zizouhuweidi/adhanctl/internal/provider"
+)
+
+// isMusafir reports whether travel prayer rules apply: forced with
+// --musafir or `musafir = on`, or with `musafir = auto` once we are at
+// least musafir_distance from the home location.
+func isMusafir(cfg *config.Config, f *flags, day *provider.Day) bool {
+	if f.musafir {
+		return true
+	}
+
+	switch strings.ToLower(cfg.Musafir) {
+	case "on", "true":
+		return true
+	case "auto":
+	default:
+		return false
+	}
+
+	lat, lon := f.latitude, f.longitude
+	if lat == 0 && lon == 0 && day != nil {
+		lat, lon = day.Latitude, day.Longitude
+	}
+	if lat == 0 && lon == 0 {
+		return false
+	}
+
+	homeLat, homeLon, ok := homeCoordinates(cfg)
+	if !ok {
+		slog.Debug("musafir auto: home location unknown")
+		return false
+	}
+
+	dist := geo.Distance(homeLat, homeLon, lat, lon)
+	slog.Debug("musafir auto", "distance_km", dist, "threshold_km", cfg.MusafirDistance)
+	return dist >= cfg.MusafirDistance
+}
+
+func homeCoordinates(cfg *config.Config) (float64, float64, bool) {
+	home, err := cfg.Home()
+	if err != nil {
+		slog.Warn("ignoring home_profile", "error", err)
+		return 0, 0, false
+	}
+
+	if home.HasCoordinates() {
+		return home.Latitude, home.Longitude, true
+	}
+	if city, ok := geo.LookupCity(home.City, home.Country); ok && home.City != "" {
+		return city.Latitude, city.Longitude, true
+	}
+	return 0, 0, false
+}
+
+// combinations returns today's combinable pairs when travelling and
+// combining is enabled.
+func combinations(cfg *config.Config, musafir bool, events []prayer.Event) []prayer.Combination {
+	if !musafir || !cfg.Combine {
+		return nil
+	}
+	return prayer.Combinations(events, ishaEnd(cfg))
+}
+
+func qasrMarker(musafir bool, name string) string {
+	if musafir && prayer.Qasr(name) {
+		return " (qasr)"
+	}
+	return ""
+}
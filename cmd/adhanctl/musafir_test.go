@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+	"github.com/zizouhuweidi/adhanctl/internal/provider"
+)
+
+func TestParseEventsFriday(t *testing.T) {
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		date    time.Time
+		musafir bool
+		want    string
+		khutbah bool
+	}{
+		{name: "resident on friday", date: friday, want: prayer.Jumuah, khutbah: true},
+		{name: "musafir on friday", date: friday, musafir: true, want: "Dhuhr"},
+		{name: "resident on thursday", date: friday.AddDate(0, 0, -1), want: "Dhuhr"},
+	}
+
+	cfg := config.Default()
+	cfg.JumuahKhutbah = "13:15"
+	cfg.JumuahIqamah = "13:45"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := &provider.Day{Date: tt.date}
+			for _, e := range [][2]string{
+				{"Fajr", "05:10"}, {"Sunrise", "06:30"}, {"Dhuhr", "12:05"},
+				{"Asr", "15:20"}, {"Maghrib", "17:45"}, {"Isha", "19:00"},
+			} {
+				at, _ := prayer.ClockOn(tt.date, e[1])
+				day.Events = append(day.Events, prayer.Event{Name: e[0], When: at})
+			}
+
+			events := parseEvents(cfg, &flags{musafir: tt.musafir}, day)
+
+			var midday *prayer.Event
+			for i := range events {
+				if prayer.IsDhuhr(events[i].Name) {
+					midday = &events[i]
+				}
+			}
+			if midday == nil {
+				t.Fatalf("no midday prayer in %v", events)
+			}
+			if midday.Name != tt.want {
+				t.Errorf("got %q, want %q", midday.Name, tt.want)
+			}
+			if midday.When.Format("15:04") != "12:05" {
+				t.Errorf("got %s, want the computed 12:05", midday.When.Format("15:04"))
+			}
+			if !midday.Khutbah.IsZero() != tt.khutbah {
+				t.Errorf("got khutbah %v, want khutbah set %t", midday.Khutbah, tt.khutbah)
+			}
+			if tt.musafir && len(prayer.Combinations(events, prayer.IshaEndMidnight)) != 2 {
+				t.Errorf("musafir on friday can't combine Dhuhr and Asr")
+			}
+		})
+	}
+}
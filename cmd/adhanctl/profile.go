@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+)
+
+func runProfile(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "profile subcommand required: list, use")
+		os.Exit(1)
+	}
+
+	sub := args[0]
+	subArgs := args[1:]
+
+	switch sub {
+	case "list", "ls":
+		runProfileList(subArgs)
+	case "use":
+		runProfileUse(subArgs)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown profile subcommand: %s\n", sub)
+		os.Exit(1)
+	}
+}
+
+func runProfileList(args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	names := cfg.ProfileNames()
+	if len(names) == 0 {
+		fmt.Printf("No profiles. Add a [profile.NAME] section to %s\n", config.ConfigPath())
+		return
+	}
+
+	for _, name := range names {
+		marker := " "
+		if name == cfg.Profile {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, name)
+	}
+}
+
+func runProfileUse(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: adhanctl profile use NAME (or 'default' for the top-level settings)")
+		os.Exit(1)
+	}
+
+	// Work on the file alone, so this still fixes a config whose default
+	// profile no longer exists.
+	file := readConfigFile()
+
+	name := args[0]
+	if name == "default" {
+		name = ""
+	} else if err := checkProfile(file, name); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if name == "" {
+		file.Unset("", "profile")
+	} else {
+		file.Set("", "profile", name)
+	}
+	if err := file.Write(); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if name == "" {
+		fmt.Println("Using the top-level settings by default")
+		return
+	}
+	fmt.Printf("Using profile %q by default\n", name)
+}
+
+// checkProfile reports an error unless name has a [profile.NAME] section in
+// file or in the system config.
+func checkProfile(file *config.File, name string) error {
+	names := file.Profiles()
+	if system, err := config.ReadFile(config.SystemConfigPath()); err == nil {
+		names = append(names, system.Profiles()...)
+	}
+	if slices.Contains(names, name) {
+		return nil
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("unknown profile %q: add a [profile.%s] section to %s first", name, name, file.Path)
+	}
+	return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+}
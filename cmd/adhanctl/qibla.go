@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+)
+
+func runQibla(args []string) {
+	cfg, args, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	rose := false
+	rest := make([]string, 0, len(args))
+	for _, a := range args {
+		if a == "--rose" {
+			rose = true
+		} else {
+			rest = append(rest, a)
+		}
+	}
+
+	f := parseFlags(rest, cfg)
+	setupLogger(f.verbose)
+
+	if err := validateLocation(f); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	lat, lon, _, err := resolveCoordinates(context.Background(), cfg, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	q := geo.QiblaFrom(lat, lon)
+
+	fmt.Printf("🧭 Qibla from %.4f, %.4f\n", lat, lon)
+	fmt.Printf("  Bearing:  %.1f° %s (from true north)\n", q.Bearing, geo.CompassPoint(q.Bearing))
+	fmt.Printf("  Distance: %.0f km to the Kaaba\n", q.Distance)
+
+	if rose {
+		fmt.Printf("\n%s\n", geo.Rose(q.Bearing))
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+)
+
+// configPollInterval is how often serve checks the config files for
+// changes.
+const configPollInterval = 2 * time.Second
+
+// configWatcher notices when the user or system config file is written,
+// created or removed by comparing modification times and sizes.
+type configWatcher struct {
+	paths  []string
+	stamps map[string]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func newConfigWatcher() *configWatcher {
+	w := &configWatcher{
+		paths:  []string{config.SystemConfigPath(), config.ConfigPath()},
+		stamps: make(map[string]fileStamp),
+	}
+	w.changed()
+	return w
+}
+
+// changed reports whether any watched file differs from the last call.
+func (w *configWatcher) changed() bool {
+	changed := false
+	for _, path := range w.paths {
+		var stamp fileStamp
+		if info, err := os.Stat(path); err == nil {
+			stamp = fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+		}
+		if stamp != w.stamps[path] {
+			w.stamps[path] = stamp
+			changed = true
+		}
+	}
+	return changed
+}
+
+// reloadConfig loads the config again the way serve started with it,
+// returning an error instead of exiting so the running settings can be
+// kept.
+func reloadConfig(args []string) (*config.Config, *flags, error) {
+	cfg, rest, err := loadConfig(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := parseFlags(rest, cfg)
+	if err := validateLocation(f); err != nil {
+		return nil, nil, err
+	}
+	return cfg, f, nil
+}
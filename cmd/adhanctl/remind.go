@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
+	"github.com/zizouhuweidi/adhanctl/internal/notify"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+	"github.com/zizouhuweidi/adhanctl/internal/provider"
+	"github.com/zizouhuweidi/adhanctl/internal/reminders"
+)
+
+func runRemind(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "remind subcommand required: add, list, remove")
+		os.Exit(1)
+	}
+
+	sub := args[0]
+	subArgs := args[1:]
+
+	switch sub {
+	case "add":
+		runRemindAdd(subArgs)
+	case "list", "ls":
+		runRemindList(subArgs)
+	case "remove", "rm":
+		runRemindRemove(subArgs)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown remind subcommand: %s\n", sub)
+		os.Exit(1)
+	}
+}
+
+func runRemindAdd(args []string) {
+	var date string
+	var words []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--hijri" && i+1 < len(args):
+			date = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--hijri="):
+			date = strings.TrimPrefix(args[i], "--hijri=")
+		default:
+			words = append(words, args[i])
+		}
+	}
+
+	text := strings.TrimSpace(strings.Join(words, " "))
+	if date == "" || text == "" {
+		fmt.Fprintln(os.Stderr, `usage: adhanctl remind add --hijri DAY-MONTH "text"`)
+		os.Exit(1)
+	}
+
+	day, month, err := reminders.ParseHijri(date)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	rs, err := reminders.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading reminders: %v\n", err)
+		os.Exit(1)
+	}
+
+	r := reminders.Reminder{Day: day, Month: month, Text: text}
+	rs = append(rs, r)
+	if err := reminders.Save(rs); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving reminders: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Added reminder #%d: %s on %s\n", len(rs), r.Text, r.Date())
+	if r.Day == 30 {
+		fmt.Println("In years when the month has 29 days, it comes on the 29th.")
+	}
+}
+
+func runRemindList(args []string) {
+	cfg, args, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	f := parseFlags(args, cfg)
+	setupLogger(f.verbose)
+
+	rs, err := reminders.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading reminders: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(rs) == 0 {
+		fmt.Println("No reminders. Add one with: adhanctl remind add --hijri 10-8 \"Zakat due\"")
+		return
+	}
+
+	offset := locationHijriOffset(cfg, f)
+	today := time.Now()
+
+	fmt.Printf("Reminders (%s):\n", reminders.Path())
+	for i, r := range rs {
+		next := ""
+		if d, ok := nextOccurrence(r, today, offset); ok {
+			next = "next " + d.Format("Mon 02 Jan 2006")
+		}
+		fmt.Printf("  %2d. %-22s %-30s %s\n", i+1, r.Date(), r.Text, next)
+	}
+}
+
+func runRemindRemove(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: adhanctl remind remove NUMBER")
+		os.Exit(1)
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid reminder number: %s\n", args[0])
+		os.Exit(1)
+	}
+
+	rs, err := reminders.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading reminders: %v\n", err)
+		os.Exit(1)
+	}
+
+	if n < 1 || n > len(rs) {
+		fmt.Fprintf(os.Stderr, "no reminder #%d, see 'adhanctl remind list'\n", n)
+		os.Exit(1)
+	}
+
+	removed := rs[n-1]
+	rs = append(rs[:n-1], rs[n:]...)
+	if err := reminders.Save(rs); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving reminders: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Removed reminder: %s on %s\n", removed.Text, removed.Date())
+}
+
+func nextOccurrence(r reminders.Reminder, from time.Time, offset int) (time.Time, bool) {
+	for i := range 400 {
+		day := from.AddDate(0, 0, i)
+		if len(reminders.Due([]reminders.Reminder{r}, hijri.FromTime(day.AddDate(0, 0, offset)))) > 0 {
+			return day, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// scheduleReminders fires personal reminders at Maghrib, when the Hijri
+// date they are keyed to begins.
+func scheduleReminders(sched *scheduler, day *provider.Day, events []prayer.Event) {
+	rs, err := reminders.Load()
+	if err != nil || len(rs) == 0 {
+		return
+	}
+
+	for _, ev := range events {
+		if ev.Name != "Maghrib" {
+			continue
+		}
+
+		h := hijri.FromTime(ev.When.AddDate(0, 0, 1+hijriOffset(day)))
+		for _, r := range reminders.Due(rs, h) {
+			sched.schedule("reminder:"+r.Text, ev.When, func() {
+				notify.Reminder("📌 "+r.Text, h.String()+" begins at Maghrib")
+			})
+		}
+	}
+}
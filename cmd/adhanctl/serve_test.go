@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+)
+
+// TestWindowWarningAfterReload fires a window warning after the config it
+// was scheduled under has changed, which -race reports if the timer reads
+// the config.
+func TestWindowWarningAfterReload(t *testing.T) {
+	// Print notifications instead of sending them.
+	t.Setenv("PATH", "")
+
+	now := time.Now()
+	events := []prayer.Event{
+		{Name: "Fajr", When: now.Add(-time.Hour)},
+		{Name: "Sunrise", When: now.Add(100 * time.Millisecond)},
+	}
+
+	cfg := config.Default()
+	cfg.WindowWarn = 50 * time.Millisecond
+
+	sched := newScheduler()
+	defer sched.stop()
+	scheduleWindowWarnings(cfg, sched, events, nil)
+
+	sched.mu.Lock()
+	scheduled := len(sched.timers)
+	sched.mu.Unlock()
+	if scheduled != 1 {
+		t.Fatalf("got %d timers, want 1", scheduled)
+	}
+
+	cfg.WindowWarn = time.Hour
+	time.Sleep(150 * time.Millisecond)
+
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	if len(sched.timers) != 0 {
+		t.Errorf("warning didn't fire")
+	}
+}
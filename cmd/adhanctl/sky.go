@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/astro"
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+)
+
+// resolveCoordinates returns the configured coordinates, or those the API
+// resolved for a city lookup, along with the location's timezone.
+func resolveCoordinates(ctx context.Context, cfg *config.Config, f *flags) (float64, float64, *time.Location, error) {
+	if f.latitude != 0 && f.longitude != 0 {
+		loc := geo.LocationAt(f.latitude, f.longitude)
+		if f.timezone != "" {
+			if l, err := time.LoadLocation(f.timezone); err == nil {
+				loc = l
+			}
+		}
+		return f.latitude, f.longitude, loc, nil
+	}
+
+	day, err := fetchPlaceDay(ctx, cfg, buildParams(f))
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("resolving coordinates: %w", err)
+	}
+	return day.Latitude, day.Longitude, day.Location(), nil
+}
+
+func loadSkyFlags(args []string) (*config.Config, *flags) {
+	cfg, args, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	f := parseFlags(args, cfg)
+	setupLogger(f.verbose)
+
+	if err := validateLocation(f); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	return cfg, f
+}
+
+func runSun(args []string) {
+	cfg, f := loadSkyFlags(args)
+
+	lat, lon, loc, err := resolveCoordinates(context.Background(), cfg, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now().In(loc)
+	day := astro.NewSolarDay(now, lat, lon)
+	day.Elevation = f.elevation
+
+	fmt.Printf("\n☀️  Sun — %s (%.4f, %.4f, %s)\n\n", now.Format("Mon 02 Jan 2006"), lat, lon, loc)
+
+	rows := []struct {
+		name     string
+		altitude float64
+		evening  bool
+	}{
+		{"Astronomical dawn", astro.AltitudeAstronomical, false},
+		{"Nautical dawn", astro.AltitudeNautical, false},
+		{"Civil dawn", astro.AltitudeCivil, false},
+		{"Sunrise", astro.SunriseAltitude(f.elevation), false},
+		{"Sunset", astro.SunriseAltitude(f.elevation), true},
+		{"Civil dusk", astro.AltitudeCivil, true},
+		{"Nautical dusk", astro.AltitudeNautical, true},
+		{"Astronomical dusk", astro.AltitudeAstronomical, true},
+	}
+
+	for i, r := range rows {
+		if i == 4 {
+			fmt.Printf("  %-18s %s (altitude %.1f°)\n", "Solar noon",
+				prayer.FormatTime(day.Noon(), f.ampm), day.NoonAltitude())
+		}
+		t, ok := day.TimeAtAltitude(r.altitude, r.evening)
+		if !ok {
+			fmt.Printf("  %-18s —\n", r.name)
+			continue
+		}
+		fmt.Printf("  %-18s %s\n", r.name, prayer.FormatTime(t, f.ampm))
+	}
+
+	fmt.Printf("\n  %-18s %s\n", "Day length", prayer.HumanDuration(day.DayLength()))
+}
+
+func runMoon(args []string) {
+	cfg, f := loadSkyFlags(args)
+
+	lat, lon, loc, err := resolveCoordinates(context.Background(), cfg, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now().In(loc)
+	phase := astro.Phase(now)
+	next := astro.NextNewMoon(now).In(loc)
+
+	fmt.Printf("\n🌙 Moon — %s (%.4f, %.4f, %s)\n\n", now.Format("Mon 02 Jan 2006"), lat, lon, loc)
+	fmt.Printf("  %-14s %s (%.0f%% illuminated)\n", "Phase", phase.Name(), phase.Illumination*100)
+	fmt.Printf("  %-14s %.1f days\n", "Age", phase.Age.Hours()/24)
+	fmt.Printf("  %-14s %s %s\n", "Next new moon", next.Format("Mon 02 Jan 2006"), prayer.FormatTime(next, f.ampm))
+
+	fmt.Println("\n  Crescent (estimated from age at sunset):")
+	for i := range 2 {
+		sunset, ok := astro.NewSolarDay(next.AddDate(0, 0, i), lat, lon).TimeAtAltitude(astro.AltitudeSunrise, true)
+		if !ok {
+			continue
+		}
+		age := sunset.Sub(next)
+		fmt.Printf("    %s evening: age %s — %s\n",
+			sunset.Format("Mon 02 Jan"), prayer.HumanDuration(age), astro.CrescentVisibility(age))
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/timetable"
+)
+
+func runTimetable(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "timetable subcommand required: import")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "import":
+		runTimetableImport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown timetable subcommand: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// prayerColumns collects repeated PRAYER=COLUMN flags.
+type prayerColumns map[string]string
+
+func (p prayerColumns) String() string { return "" }
+
+func (p prayerColumns) Set(value string) error {
+	name, col, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(col) == "" {
+		return fmt.Errorf("expected PRAYER=COLUMN, got %q", value)
+	}
+	prayer, ok := timetable.CanonicalPrayer(name)
+	if !ok {
+		return fmt.Errorf("unknown prayer %q", name)
+	}
+	p[prayer] = strings.TrimSpace(col)
+	return nil
+}
+
+func runTimetableImport(args []string) {
+	cfg, args, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	adhan, iqamah := prayerColumns{}, prayerColumns{}
+	fs := flag.NewFlagSet("timetable import", flag.ContinueOnError)
+	fs.Var(adhan, "adhan", "column (or ICS summary) with a prayer's adhan time, as PRAYER=COLUMN")
+	fs.Var(iqamah, "iqamah", "column (or ICS summary) with a prayer's iqamah time, as PRAYER=COLUMN")
+	dateCol := fs.String("date", "", "column with the date")
+	dateFormat := fs.String("date-format", "", "date format, e.g. DD/MM/YYYY")
+
+	// Allow the file before or after the flags.
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			os.Exit(2)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: adhanctl timetable import [--adhan PRAYER=COLUMN]... [--iqamah PRAYER=COLUMN]... [--date COLUMN] [--date-format FORMAT] FILE")
+		os.Exit(1)
+	}
+
+	opts := timetable.Options{
+		Adhan:      adhan,
+		Iqamah:     iqamah,
+		DateColumn: *dateCol,
+		DateFormat: *dateFormat,
+		Location:   configLocation(cfg),
+	}
+
+	rows, mapping, err := timetable.Import(files[0], opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error importing %s: %v\n", files[0], err)
+		os.Exit(1)
+	}
+
+	path := timetablePath(cfg)
+	if err := timetable.Write(path, rows); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	printMapping(mapping)
+	first, last := timetable.Span(rows)
+	fmt.Printf("\nImported %s (%s to %s) into %s\n", plural(len(rows), "day"),
+		first.Format("2 Jan 2006"), last.Format("2 Jan 2006"), path)
+
+	if gaps := timetable.Gaps(rows); len(gaps) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: missing %s: %s\n", plural(len(gaps), "day"), formatDates(gaps))
+	}
+	if cfg.Provider != "timetable" {
+		fmt.Println("Run 'adhanctl config set source timetable' to use it.")
+	}
+}
+
+func printMapping(m timetable.Mapping) {
+	if m.Date != "" {
+		fmt.Printf("  %-8s ← %s\n", "Date", m.Date)
+	}
+	for _, p := range timetable.Prayers {
+		var parts []string
+		if col, ok := m.Adhan[p]; ok {
+			parts = append(parts, col)
+		}
+		if col, ok := m.Iqamah[p]; ok {
+			parts = append(parts, "iqamah "+col)
+		}
+		if len(parts) > 0 {
+			fmt.Printf("  %-8s ← %s\n", p, strings.Join(parts, ", "))
+		}
+	}
+}
+
+// formatDates lists up to a handful of dates, then how many more.
+func formatDates(dates []time.Time) string {
+	dates = slices.Clone(dates)
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	const shown = 5
+	var parts []string
+	for i, d := range dates {
+		if i == shown {
+			parts = append(parts, fmt.Sprintf("and %d more", len(dates)-shown))
+			break
+		}
+		parts = append(parts, d.Format("2 Jan 2006"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func timetablePath(cfg *config.Config) string {
+	if cfg.Timetable != "" {
+		return cfg.Timetable
+	}
+	return timetable.DefaultPath()
+}
+
+// configLocation returns the timezone of the configured location, or the
+// system's when there is none.
+func configLocation(cfg *config.Config) *time.Location {
+	f := parseFlags(nil, cfg)
+	if err := validateLocation(f); err != nil {
+		return time.Local
+	}
+	return f.location()
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
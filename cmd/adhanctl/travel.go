@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/travel"
+)
+
+// moveThresholdKm is how far a coordinate hint must be from the current
+// location before serve treats it as travel rather than GPS jitter.
+const moveThresholdKm = 25
+
+// nearbyCityKm is how close a named city must be to describe a position.
+const nearbyCityKm = 50
+
+type traveller struct {
+	detector *travel.Detector
+	lastKey  string
+
+	// home is the configured location, as the flags had it, and homeAt
+	// where it is, with its zone, to recognise hints that point back
+	// there.
+	home   position
+	homeAt position
+
+	// at is where the last move went, or nil while serve is at the
+	// configured location.
+	at *position
+}
+
+// position is a place serve schedules times for.
+type position struct {
+	latitude, longitude float64
+	timezone            string
+	city, country       string
+}
+
+func newTraveller(cfg *config.Config, f *flags) *traveller {
+	t := &traveller{detector: travel.NewDetector(cfg.LocationFile, cfg.GPSD)}
+	t.setHome(f)
+	return t
+}
+
+// setHome records f, freshly built from the config, as the configured
+// location.
+func (t *traveller) setHome(f *flags) {
+	t.home = position{
+		latitude:  f.latitude,
+		longitude: f.longitude,
+		timezone:  f.timezone,
+		city:      f.city,
+		country:   f.country,
+	}
+	t.homeAt = position{latitude: f.latitude, longitude: f.longitude, timezone: f.location().String()}
+}
+
+// sameSource reports whether t reads the hints cfg asks for, so it can be
+// kept across a config reload.
+func (t *traveller) sameSource(cfg *config.Config) bool {
+	d := travel.NewDetector(cfg.LocationFile, cfg.GPSD)
+	return d.LocationFile == t.detector.LocationFile && d.GPSD == t.detector.GPSD
+}
+
+// follow polls the location hints and moves f when they point somewhere
+// new. here is the place the current times are for, with the coordinates
+// and zone the source resolved. It returns a name for the new place when
+// the location changed.
+//
+// The first timezone hint is only a baseline: the system zone often
+// differs from the configured location's without anyone travelling, so
+// only a later change of zone counts as a move. Coordinate hints are
+// precise enough to act on straight away when they are far from here.
+// A hint that points back to the configured location returns f to it.
+func (t *traveller) follow(ctx context.Context, f *flags, here position) (string, bool) {
+	hint, ok := t.detector.Current(ctx)
+	if !ok {
+		return "", false
+	}
+
+	// The source knows the configured location best while we are there.
+	if t.at == nil {
+		t.homeAt = here
+	}
+
+	key := hint.Key()
+	first := t.lastKey == ""
+	if key == t.lastKey {
+		return "", false
+	}
+	t.lastKey = key
+
+	if t.isHome(hint) {
+		if t.at == nil {
+			return "", false
+		}
+		slog.Info("back at the configured location", "source", hint.Source)
+		t.goHome(f)
+		return t.homeName(), true
+	}
+
+	if hint.HasCoordinates() {
+		if here.latitude != 0 || here.longitude != 0 {
+			if geo.Distance(here.latitude, here.longitude, hint.Latitude, hint.Longitude) < moveThresholdKm {
+				return "", false
+			}
+		}
+
+		name := fmt.Sprintf("%.4f, %.4f", hint.Latitude, hint.Longitude)
+		if city, dist := geo.NearestCity(hint.Latitude, hint.Longitude); dist <= nearbyCityKm {
+			name = city.Name
+		}
+
+		slog.Info("location changed", "source", hint.Source, "lat", hint.Latitude, "lon", hint.Longitude)
+		t.moveTo(f, position{
+			latitude:  hint.Latitude,
+			longitude: hint.Longitude,
+			timezone:  geo.TimezoneAt(hint.Latitude, hint.Longitude),
+		})
+		return name, true
+	}
+
+	if first {
+		slog.Debug("system timezone", "timezone", hint.Timezone)
+		return "", false
+	}
+	if hint.Timezone == here.timezone {
+		return "", false
+	}
+
+	city, ok := geo.LargestCityIn(hint.Timezone)
+	if !ok {
+		slog.Warn("timezone changed but no known city in it", "timezone", hint.Timezone)
+		return "", false
+	}
+
+	slog.Info("timezone changed", "timezone", hint.Timezone, "city", city)
+	t.moveTo(f, position{
+		latitude:  city.Latitude,
+		longitude: city.Longitude,
+		timezone:  city.Timezone,
+		city:      city.Name,
+		country:   city.Country,
+	})
+	return city.Name, true
+}
+
+// reapply moves f, freshly built from a reloaded config, to where the last
+// move went.
+func (t *traveller) reapply(f *flags) {
+	t.setHome(f)
+	if t.at != nil {
+		t.moveTo(f, *t.at)
+	}
+}
+
+// isHome reports whether hint points to the configured location.
+func (t *traveller) isHome(hint travel.Hint) bool {
+	if hint.HasCoordinates() {
+		home := t.homeAt
+		return (home.latitude != 0 || home.longitude != 0) &&
+			geo.Distance(home.latitude, home.longitude, hint.Latitude, hint.Longitude) < moveThresholdKm
+	}
+	return hint.Timezone == t.homeAt.timezone
+}
+
+// goHome returns f to the configured location.
+func (t *traveller) goHome(f *flags) {
+	t.at = nil
+	f.latitude, f.longitude = t.home.latitude, t.home.longitude
+	f.timezone = t.home.timezone
+	f.city, f.country = t.home.city, t.home.country
+}
+
+// homeName describes the configured location for the move notification.
+func (t *traveller) homeName() string {
+	if t.home.city != "" {
+		return t.home.city
+	}
+	return fmt.Sprintf("%.4f, %.4f", t.home.latitude, t.home.longitude)
+}
+
+func (t *traveller) moveTo(f *flags, p position) {
+	t.at = &p
+	f.latitude, f.longitude = p.latitude, p.longitude
+	f.timezone = p.timezone
+	f.city, f.country = p.city, p.country
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+)
+
+func TestTravelRoundTrip(t *testing.T) {
+	cfg := config.Default()
+	cfg.LocationFile = filepath.Join(t.TempDir(), "location")
+
+	homeFlags := func() *flags {
+		return &flags{city: "Birmingham", country: "United Kingdom", latitude: 52.4862, longitude: -1.8904, timezone: "Europe/London"}
+	}
+	f := homeFlags()
+	trav := newTraveller(cfg, f)
+	home := position{latitude: f.latitude, longitude: f.longitude, timezone: "Europe/London"}
+	ctx := context.Background()
+
+	t.Setenv("TZ", "Europe/London")
+	if _, moved := trav.follow(ctx, f, home); moved {
+		t.Fatal("moved on the first timezone hint")
+	}
+
+	t.Setenv("TZ", "Europe/Paris")
+	place, moved := trav.follow(ctx, f, home)
+	if !moved || place != "Paris" || f.city != "Paris" {
+		t.Fatalf("got %q, moved %t, city %q; want a move to Paris", place, moved, f.city)
+	}
+	away := position{latitude: f.latitude, longitude: f.longitude, timezone: "Europe/Paris"}
+
+	t.Setenv("TZ", "Europe/London")
+	place, moved = trav.follow(ctx, f, away)
+	if !moved || place != "Birmingham" {
+		t.Fatalf("got %q, moved %t; want a move back to Birmingham", place, moved)
+	}
+	if f.city != "Birmingham" || f.latitude != 52.4862 || f.longitude != -1.8904 {
+		t.Errorf("got %s at %.4f, %.4f; want the configured Birmingham", f.city, f.latitude, f.longitude)
+	}
+	if trav.at != nil {
+		t.Errorf("still travelling to %+v", *trav.at)
+	}
+
+	reloaded := homeFlags()
+	trav.reapply(reloaded)
+	if reloaded.city != "Birmingham" {
+		t.Errorf("reload moved to %q, want Birmingham", reloaded.city)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/api"
+	"github.com/zizouhuweidi/adhanctl/internal/config"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+)
+
+// worldParallelism bounds concurrent API requests for the world command.
+const worldParallelism = 4
+
+type worldCity struct {
+	name   string
+	loc    *time.Location
+	events []prayer.Event
+	err    error
+}
+
+func runWorld(args []string) {
+	cfg, args, err := loadConfig(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	names, rest := splitWorldArgs(args)
+	f := parseFlags(rest, cfg)
+	setupLogger(f.verbose)
+
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, `usage: adhanctl world CITY [CITY...] (use "City,Country" to disambiguate)`)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	cities := make([]worldCity, len(names))
+	sem := make(chan struct{}, worldParallelism)
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			cities[i] = fetchWorldCity(ctx, cfg, f, name)
+		}()
+	}
+	wg.Wait()
+
+	printWorld(cities, f.ampm)
+}
+
+// splitWorldArgs separates city names from flags, keeping each flag's
+// value with it so "--method 4" isn't mistaken for a city named "4".
+func splitWorldArgs(args []string) (names, rest []string) {
+	fs := newFlagSet(&flags{})
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			names = append(names, args[i])
+			continue
+		}
+		rest = append(rest, args[i])
+		if needsValue(fs, args[i]) && i+1 < len(args) {
+			rest = append(rest, args[i+1])
+			i++
+		}
+	}
+	return names, rest
+}
+
+// needsValue reports whether arg is a flag in fs that takes a separate
+// value argument. Bool flags don't, nor do flags written as -name=value.
+func needsValue(fs *flag.FlagSet, arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+	fl := fs.Lookup(strings.TrimLeft(arg, "-"))
+	if fl == nil {
+		return false
+	}
+	b, ok := fl.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
+func fetchWorldCity(ctx context.Context, cfg *config.Config, f *flags, name string) worldCity {
+	params := api.TimingsParams{
+		Method: f.method,
+		School: f.school,
+		Date:   time.Now(),
+	}
+	city, country, _ := strings.Cut(name, ",")
+	city, country = strings.TrimSpace(city), strings.TrimSpace(country)
+
+	if known, ok := geo.LookupCity(city, country); ok {
+		params.Latitude, params.Longitude = known.Latitude, known.Longitude
+		name = known.Name
+		if loc, err := time.LoadLocation(known.Timezone); err == nil {
+			params.Date = time.Now().In(loc)
+		}
+	} else if country != "" {
+		params.City, params.Country = city, country
+		name = city
+	} else {
+		params.Address = name
+	}
+
+	day, err := fetchPlaceDay(ctx, cfg, params)
+	if err != nil {
+		return worldCity{name: name, err: err}
+	}
+
+	// Until the city's timezone is known the day asked for is ours, which
+	// may not be the city's today.
+	if now := time.Now().In(day.Location()); now.Format("2006-01-02") != day.Date.Format("2006-01-02") {
+		params.Date = now
+		if day, err = fetchPlaceDay(ctx, cfg, params); err != nil {
+			return worldCity{name: name, err: err}
+		}
+	}
+	return worldCity{name: name, loc: day.Location(), events: day.Events}
+}
+
+func printWorld(cities []worldCity, ampm bool) {
+	const width = 20
+
+	row := func(label string, cell func(c worldCity) string) {
+		fmt.Printf("%-9s", label)
+		for _, c := range cities {
+			if c.err != nil {
+				fmt.Printf("%-*s", width, "—")
+				continue
+			}
+			fmt.Printf("%-*s", width, cell(c))
+		}
+		fmt.Println()
+	}
+
+	fmt.Println()
+	fmt.Printf("%-9s", "")
+	for _, c := range cities {
+		fmt.Printf("%-*s", width, c.name)
+	}
+	fmt.Println()
+	row("Timezone", func(c worldCity) string { return c.loc.String() })
+	row("Now", func(c worldCity) string { return prayer.FormatTime(time.Now().In(c.loc), ampm) })
+	fmt.Println(strings.Repeat("-", 9+width*len(cities)))
+
+	for _, name := range prayer.StandardOrder {
+		row(name, func(c worldCity) string {
+			for _, e := range c.events {
+				if e.Name == name {
+					return fmt.Sprintf("%s (%s)", prayer.FormatTime(e.When, ampm), prayer.FormatTime(e.When.In(time.Local), ampm))
+				}
+			}
+			return "—"
+		})
+	}
+
+	fmt.Println(strings.Repeat("-", 9+width*len(cities)))
+	row("Next", func(c worldCity) string {
+		now := time.Now().In(c.loc)
+		next := prayer.NextEventAfter(c.events, now)
+		if next == nil {
+			return "Fajr tomorrow"
+		}
+		return fmt.Sprintf("%s in %s", next.Name, prayer.HumanDuration(next.When.Sub(now)))
+	})
+
+	fmt.Printf("\nTimes are local to each city, with your local time (%s) in brackets.\n", time.Now().Format("MST"))
+
+	for _, c := range cities {
+		if c.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, c.err)
+		}
+	}
+}
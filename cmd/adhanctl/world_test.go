@@ -0,0 +1,52 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitWorldArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		names []string
+		rest  []string
+	}{
+		{
+			name:  "value flag",
+			args:  []string{"--method", "4", "Amman", "London"},
+			names: []string{"Amman", "London"},
+			rest:  []string{"--method", "4"},
+		},
+		{
+			name:  "value after equals",
+			args:  []string{"--method=4", "Amman"},
+			names: []string{"Amman"},
+			rest:  []string{"--method=4"},
+		},
+		{
+			name:  "bool flags",
+			args:  []string{"--ampm", "Amman", "-v", "London"},
+			names: []string{"Amman", "London"},
+			rest:  []string{"--ampm", "-v"},
+		},
+		{
+			name:  "musafir",
+			args:  []string{"--musafir", "Amman", "London"},
+			names: []string{"Amman", "London"},
+			rest:  []string{"--musafir"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, rest := splitWorldArgs(tt.args)
+			if !slices.Equal(names, tt.names) {
+				t.Errorf("got cities %q, want %q", names, tt.names)
+			}
+			if !slices.Equal(rest, tt.rest) {
+				t.Errorf("got flags %q, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
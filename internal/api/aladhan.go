@@ -3,35 +3,78 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
 const (
-	BaseURL       = "https://api.aladhan.com/v1"
-	UserAgent     = "adhanctl/1.0"
-	DefaultMethod = 2
-	MaxRetries    = 6
+	BaseURL        = "https://api.aladhan.com/v1"
+	UserAgent      = "adhanctl/1.0"
+	DefaultTimeout = 10 * time.Second
+	DefaultMethod  = 2
+	MaxRetries     = 6
+
+	// MaxRetryAfter is the longest Retry-After we wait out; a server
+	// asking for longer fails the fetch instead.
+	MaxRetryAfter = 30 * time.Second
+)
+
+// firstBackoff and maxBackoff bound the wait between retries, which
+// doubles after each attempt.
+var (
+	firstBackoff = 500 * time.Millisecond
+	maxBackoff   = 8 * time.Second
 )
 
 type Client struct {
 	BaseURL    string
+	UserAgent  string
 	HTTPClient *http.Client
 	Logger     *slog.Logger
 }
 
-func NewClient() *Client {
-	return &Client{
-		BaseURL: BaseURL,
+// Options point the client at an AlAdhan-compatible server. Zero values
+// take the defaults; without Proxy the usual proxy environment variables
+// apply.
+type Options struct {
+	BaseURL   string
+	Timeout   time.Duration
+	UserAgent string
+	Proxy     *url.URL
+}
+
+func NewClient(opts Options) *Client {
+	c := &Client{
+		BaseURL:   BaseURL,
+		UserAgent: UserAgent,
 		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: DefaultTimeout,
 		},
 		Logger: slog.Default(),
 	}
+
+	if opts.BaseURL != "" {
+		c.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	}
+	if opts.UserAgent != "" {
+		c.UserAgent = opts.UserAgent
+	}
+	if opts.Timeout > 0 {
+		c.HTTPClient.Timeout = opts.Timeout
+	}
+	if opts.Proxy != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(opts.Proxy)
+		c.HTTPClient.Transport = transport
+	}
+	return c
 }
 
 type Response struct {
@@ -95,6 +138,7 @@ type Meta struct {
 }
 
 type TimingsParams struct {
+	Address   string
 	City      string
 	Country   string
 	Latitude  float64
@@ -105,12 +149,67 @@ type TimingsParams struct {
 }
 
 func (c *Client) FetchTimings(ctx context.Context, params TimingsParams) (*Response, error) {
+	return c.fetchWithRetries(ctx, c.timingsURL(params))
+}
+
+// Probe fetches params once, without retrying, and checks the response
+// has the fields adhanctl reads. It returns the problems found, which are
+// empty for a compatible server.
+func (c *Client) Probe(ctx context.Context, params TimingsParams) ([]string, error) {
+	resp, err := c.fetchURL(ctx, c.timingsURL(params))
+	if err != nil {
+		return nil, err
+	}
+	return CheckSchema(resp), nil
+}
+
+// CheckSchema reports what is missing or malformed in a timings response.
+func CheckSchema(resp *Response) []string {
+	var problems []string
+
+	for _, name := range []string{"Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"} {
+		value, ok := resp.Data.Timings[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("data.timings.%s is missing", name))
+			continue
+		}
+		clock, _, _ := strings.Cut(value, " ")
+		if _, err := time.Parse("15:04", clock); err != nil {
+			problems = append(problems, fmt.Sprintf("data.timings.%s: expected HH:MM, got %q", name, value))
+		}
+	}
+
+	if _, err := time.Parse("02-01-2006", resp.Data.Date.Gregorian.Date); err != nil {
+		problems = append(problems, fmt.Sprintf("data.date.gregorian.date: expected DD-MM-YYYY, got %q", resp.Data.Date.Gregorian.Date))
+	}
+
+	h := resp.Data.Date.Hijri
+	if h.Month.Number < 1 || h.Month.Number > 12 || h.Day == "" || h.Year == "" {
+		problems = append(problems, "data.date.hijri: day, month.number or year is missing")
+	}
+
+	if tz := resp.Data.Meta.Timezone; tz == "" {
+		problems = append(problems, "data.meta.timezone is missing")
+	} else if _, err := time.LoadLocation(tz); err != nil {
+		problems = append(problems, fmt.Sprintf("data.meta.timezone: unknown zone %q", tz))
+	}
+	if resp.Data.Meta.Latitude == 0 && resp.Data.Meta.Longitude == 0 {
+		problems = append(problems, "data.meta.latitude and longitude are missing")
+	}
+
+	return problems
+}
+
+func (c *Client) timingsURL(params TimingsParams) string {
 	var apiURL string
 	dateStr := params.Date.Format("02-01-2006")
 
 	if params.Latitude != 0 && params.Longitude != 0 {
 		apiURL = fmt.Sprintf("%s/timings/%s?latitude=%f&longitude=%f&method=%d",
 			c.BaseURL, dateStr, params.Latitude, params.Longitude, params.Method)
+	} else if params.Address != "" {
+		apiURL = fmt.Sprintf("%s/timingsByAddress/%s?address=%s&method=%d",
+			c.BaseURL, dateStr, url.QueryEscape(params.Address), params.Method)
 	} else {
 		apiURL = fmt.Sprintf("%s/timingsByCity/%s?city=%s&country=%s&method=%d",
 			c.BaseURL, dateStr,
@@ -122,38 +221,58 @@ func (c *Client) FetchTimings(ctx context.Context, params TimingsParams) (*Respo
 	if params.School != 0 {
 		apiURL += fmt.Sprintf("&school=%d", params.School)
 	}
-
-	return c.fetchWithRetries(ctx, apiURL)
+	return apiURL
 }
 
+// fetchWithRetries retries network, server and rate-limit failures with
+// jittered exponential backoff, waiting at least as long as a Retry-After
+// header asks. Anything else, such as an unknown city, fails at once.
 func (c *Client) fetchWithRetries(ctx context.Context, apiURL string) (*Response, error) {
 	var lastErr error
-	backoff := 500 * time.Millisecond
+	backoff := firstBackoff
 
 	for i := range MaxRetries {
 		resp, err := c.fetchURL(ctx, apiURL)
 		if err == nil {
 			return resp, nil
 		}
+		if !Transient(err) {
+			return nil, err
+		}
 		lastErr = err
+		if i == MaxRetries-1 {
+			break
+		}
+
+		// Wait between half and all of the backoff so clients started
+		// together don't retry together.
+		wait := backoff/2 + rand.N(backoff/2+1)
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			if apiErr.RetryAfter > MaxRetryAfter {
+				return nil, err
+			}
+			wait = max(wait, apiErr.RetryAfter)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, err
+		}
+
 		c.Logger.Debug("fetch attempt failed, retrying",
 			"attempt", i+1,
 			"error", err,
-			"backoff", backoff)
+			"wait", wait)
 
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(backoff):
+		case <-time.After(wait):
 		}
 
-		backoff *= 2
-		if backoff > 8*time.Second {
-			backoff = 8 * time.Second
-		}
+		backoff = min(backoff*2, maxBackoff)
 	}
 
-	return nil, fmt.Errorf("fetch failed after %d retries: %w", MaxRetries, lastErr)
+	return nil, fmt.Errorf("fetch failed after %d attempts: %w", MaxRetries, lastErr)
 }
 
 func (c *Client) fetchURL(ctx context.Context, apiURL string) (*Response, error) {
@@ -161,26 +280,31 @@ func (c *Client) fetchURL(ctx context.Context, apiURL string) (*Response, error)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", c.UserAgent)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &Error{Kind: ErrNetwork, Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return nil, fmt.Errorf("api status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp.StatusCode, errorMessage(body), resp.Header)
 	}
 
 	var result Response
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+		// A truncated body or a proxy's HTML page is the server's
+		// failure, and may not happen again.
+		return nil, &Error{Kind: ErrServer, Status: resp.StatusCode, Err: fmt.Errorf("decoding response: %w", err)}
 	}
 
 	if result.Code != 200 {
-		return &result, fmt.Errorf("api error code %d: %s", result.Code, result.Msg)
+		return &result, statusError(result.Code, result.Msg, resp.Header)
 	}
 
 	return &result, nil
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const okBody = `{"code":200,"status":"OK","data":{"timings":{"Fajr":"05:10"}}}`
+
+// newTestClient returns a client for a server running handler, and a
+// count of the requests the server has had.
+func newTestClient(t *testing.T, handler func(w http.ResponseWriter, attempt int)) (*Client, *atomic.Int32) {
+	t.Helper()
+
+	saved := firstBackoff
+	firstBackoff = time.Millisecond
+	t.Cleanup(func() { firstBackoff = saved })
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, int(attempts.Add(1)))
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewClient(Options{BaseURL: srv.URL}), &attempts
+}
+
+func fetch(c *Client) (*Response, error) {
+	return c.FetchTimings(context.Background(), TimingsParams{City: "Amman", Country: "Jordan", Method: 23, Date: time.Now()})
+}
+
+func TestInvalidLocation(t *testing.T) {
+	c, attempts := newTestClient(t, func(w http.ResponseWriter, attempt int) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":400,"status":"BAD_REQUEST","data":"Unable to geocode address."}`))
+	})
+
+	_, err := fetch(c)
+	if !errors.Is(err, ErrInvalidLocation) {
+		t.Fatalf("got %v, want ErrInvalidLocation", err)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Message != "Unable to geocode address." {
+		t.Errorf("got %v, want the message from the body", err)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("got %d attempts, want 1", n)
+	}
+}
+
+func TestRetryAfterHonoured(t *testing.T) {
+	c, attempts := newTestClient(t, func(w http.ResponseWriter, attempt int) {
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(okBody))
+	})
+
+	start := time.Now()
+	resp, err := fetch(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Timings["Fajr"] != "05:10" {
+		t.Errorf("got timings %v", resp.Data.Timings)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, want at least the 1s Retry-After", waited)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("got %d attempts, want 2", n)
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	c, attempts := newTestClient(t, func(w http.ResponseWriter, attempt int) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	start := time.Now()
+	_, err := fetch(c)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got %v, want ErrRateLimited", err)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Hour {
+		t.Errorf("got %v, want a Retry-After of 1h", err)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("got %d attempts, want 1", n)
+	}
+	if waited := time.Since(start); waited > MaxRetryAfter/2 {
+		t.Errorf("failed after %s, want at once", waited)
+	}
+}
+
+func TestServerErrorRetried(t *testing.T) {
+	c, attempts := newTestClient(t, func(w http.ResponseWriter, attempt int) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := fetch(c)
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("got %v, want ErrServer", err)
+	}
+	if n := attempts.Load(); n != MaxRetries {
+		t.Errorf("got %d attempts, want %d", n, MaxRetries)
+	}
+}
+
+func TestClosedConnection(t *testing.T) {
+	c, attempts := newTestClient(t, func(w http.ResponseWriter, attempt int) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	})
+
+	_, err := fetch(c)
+	if !errors.Is(err, ErrNetwork) {
+		t.Fatalf("got %v, want ErrNetwork", err)
+	}
+	if n := attempts.Load(); n != MaxRetries {
+		t.Errorf("got %d attempts, want %d", n, MaxRetries)
+	}
+}
+
+func TestUndecodableResponseRetried(t *testing.T) {
+	c, attempts := newTestClient(t, func(w http.ResponseWriter, attempt int) {
+		if attempt == 1 {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Bad gateway</body></html>"))
+			return
+		}
+		if attempt == 2 {
+			w.Write([]byte(okBody[:20]))
+			return
+		}
+		w.Write([]byte(okBody))
+	})
+
+	if _, err := fetch(c); err != nil {
+		t.Fatalf("got %v, want a retry past the bad responses", err)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("got %d attempts, want 3", n)
+	}
+}
+
+func TestUndecodableResponseIsServerError(t *testing.T) {
+	c, attempts := newTestClient(t, func(w http.ResponseWriter, attempt int) {
+		w.Write([]byte("<html><body>Bad gateway</body></html>"))
+	})
+
+	_, err := fetch(c)
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("got %v, want ErrServer", err)
+	}
+	if n := attempts.Load(); n != MaxRetries {
+		t.Errorf("got %d attempts, want %d", n, MaxRetries)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The kinds of failure a fetch can end in. Match them with errors.Is.
+var (
+	// ErrInvalidLocation means the API couldn't find or geocode the
+	// location asked for. Retrying won't help.
+	ErrInvalidLocation = errors.New("location not found")
+
+	// ErrRateLimited means the API is refusing requests for a while.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrServer means the API failed on its side (5xx).
+	ErrServer = errors.New("server error")
+
+	// ErrNetwork means the API couldn't be reached: DNS, connection,
+	// TLS, proxy or timeout failures.
+	ErrNetwork = errors.New("network error")
+)
+
+// Error is a failed request. Kind is one of the Err* values above, or nil
+// for a response that fits none of them.
+type Error struct {
+	Kind    error
+	Status  int
+	Message string
+
+	// RetryAfter is how long a 429 or 503 response asked us to wait.
+	RetryAfter time.Duration
+
+	// Err is the underlying transport error for ErrNetwork, or why an
+	// OK response couldn't be decoded for ErrServer.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+	}
+	msg := fmt.Sprintf("api status %d", e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Kind != nil {
+		msg = fmt.Sprintf("%v (%s)", e.Kind, msg)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// Transient reports whether err is worth retrying.
+func Transient(err error) bool {
+	return errors.Is(err, ErrNetwork) || errors.Is(err, ErrServer) || errors.Is(err, ErrRateLimited)
+}
+
+// statusError classifies a non-200 status, from the HTTP response or from
+// the "code" field of the body.
+func statusError(status int, message string, header http.Header) *Error {
+	e := &Error{Status: status, Message: message}
+	switch {
+	case status == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+		e.RetryAfter = retryAfter(header)
+	case status >= 500:
+		e.Kind = ErrServer
+		e.RetryAfter = retryAfter(header)
+	case status == http.StatusBadRequest:
+		// AlAdhan answers 400 for a city, address or coordinates it
+		// can't use.
+		e.Kind = ErrInvalidLocation
+	}
+	return e
+}
+
+// errorMessage pulls the message out of an AlAdhan error body, which puts
+// it in "data" as a string, falling back to the raw body.
+func errorMessage(body []byte) string {
+	var payload struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		var data string
+		if json.Unmarshal(payload.Data, &data) == nil && data != "" {
+			return data
+		}
+		if payload.Status != "" {
+			return payload.Status
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// retryAfter reads a Retry-After header in seconds or as an HTTP date.
+func retryAfter(header http.Header) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return max(time.Until(when), 0)
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+const SynodicMonth = 29.530588861
+
+// Approximate TT-UT difference for the current era, in days.
+const deltaT = 69.0 / 86400
+
+type MoonPhase struct {
+	Illumination float64
+	Waxing       bool
+	Age          time.Duration
+}
+
+func (p MoonPhase) Name() string {
+	switch {
+	case p.Illumination < 0.02:
+		return "New Moon"
+	case p.Illumination > 0.98:
+		return "Full Moon"
+	case p.Illumination >= 0.48 && p.Illumination <= 0.52 && p.Waxing:
+		return "First Quarter"
+	case p.Illumination >= 0.48 && p.Illumination <= 0.52:
+		return "Last Quarter"
+	case p.Illumination < 0.5 && p.Waxing:
+		return "Waxing Crescent"
+	case p.Illumination < 0.5:
+		return "Waning Crescent"
+	case p.Waxing:
+		return "Waxing Gibbous"
+	}
+	return "Waning Gibbous"
+}
+
+// Phase returns the moon's illuminated fraction at t (Meeus, ch. 48) and
+// its age since the last new moon.
+func Phase(t time.Time) MoonPhase {
+	T := (julianDay(t) + deltaT - 2451545) / 36525
+
+	d := 297.8501921 + 445267.1114034*T - 0.0018819*T*T + T*T*T/545868 - T*T*T*T/113065000
+	m := 357.5291092 + 35999.0502909*T - 0.0001536*T*T + T*T*T/24490000
+	mp := 134.9633964 + 477198.8675055*T + 0.0087414*T*T + T*T*T/69699 - T*T*T*T/14712000
+
+	dr, mr, mpr := radians(d), radians(m), radians(mp)
+	i := 180 - d -
+		6.289*math.Sin(mpr) +
+		2.100*math.Sin(mr) -
+		1.274*math.Sin(2*dr-mpr) -
+		0.658*math.Sin(2*dr) -
+		0.214*math.Sin(2*mpr) -
+		0.110*math.Sin(dr)
+
+	return MoonPhase{
+		Illumination: (1 + math.Cos(radians(i))) / 2,
+		Waxing:       math.Mod(math.Mod(d, 360)+360, 360) < 180,
+		Age:          t.Sub(PreviousNewMoon(t)),
+	}
+}
+
+func NextNewMoon(t time.Time) time.Time {
+	k := newMoonIndex(t)
+	for {
+		nm := newMoon(k)
+		if nm.After(t) {
+			return nm
+		}
+		k++
+	}
+}
+
+func PreviousNewMoon(t time.Time) time.Time {
+	k := newMoonIndex(t) + 2
+	for {
+		nm := newMoon(k)
+		if !nm.After(t) {
+			return nm
+		}
+		k--
+	}
+}
+
+func newMoonIndex(t time.Time) float64 {
+	year := float64(t.Year()) + float64(t.YearDay())/365.25
+	return math.Floor((year-2000)*12.3685) - 1
+}
+
+// newMoon returns the instant of lunation k counted from January 2000,
+// with the main periodic terms of Meeus, ch. 49.
+func newMoon(k float64) time.Time {
+	T := k / 1236.85
+
+	jde := 2451550.09766 + SynodicMonth*k + 0.00015437*T*T - 0.000000150*T*T*T + 0.00000000073*T*T*T*T
+	e := 1 - 0.002516*T - 0.0000074*T*T
+
+	m := radians(2.5534 + 29.10535670*k - 0.0000014*T*T - 0.00000011*T*T*T)
+	mp := radians(201.5643 + 385.81693528*k + 0.0107582*T*T + 0.00001238*T*T*T - 0.000000058*T*T*T*T)
+	f := radians(160.7108 + 390.67050284*k - 0.0016118*T*T - 0.00000227*T*T*T + 0.000000011*T*T*T*T)
+	omega := radians(124.7746 - 1.56375588*k + 0.0020672*T*T + 0.00000215*T*T*T)
+
+	jde += -0.40720*math.Sin(mp) +
+		0.17241*e*math.Sin(m) +
+		0.01608*math.Sin(2*mp) +
+		0.01039*math.Sin(2*f) +
+		0.00739*e*math.Sin(mp-m) -
+		0.00514*e*math.Sin(mp+m) +
+		0.00208*e*e*math.Sin(2*m) -
+		0.00111*math.Sin(mp-2*f) -
+		0.00057*math.Sin(mp+2*f) +
+		0.00056*e*math.Sin(2*mp+m) -
+		0.00042*math.Sin(3*mp) +
+		0.00042*e*math.Sin(m+2*f) +
+		0.00038*e*math.Sin(m-2*f) -
+		0.00024*e*math.Sin(2*mp-m) -
+		0.00017*math.Sin(omega)
+
+	return fromJulianDay(jde - deltaT)
+}
+
+// CrescentVisibility gives a rough estimate of whether the new crescent
+// can be seen at sunset from the moon's age alone. Real visibility also
+// depends on the moon's altitude, lag after sunset and the sky.
+func CrescentVisibility(age time.Duration) string {
+	switch {
+	case age < 0:
+		return "not possible (before conjunction)"
+	case age < 15*time.Hour:
+		return "not visible"
+	case age < 20*time.Hour:
+		return "unlikely, may need optical aid"
+	case age < 30*time.Hour:
+		return "possibly visible to the naked eye"
+	}
+	return "easily visible"
+}
@@ -0,0 +1,180 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Standard solar altitudes, in degrees, of the events the sun command shows.
+const (
+	AltitudeSunrise      = -0.833
+	AltitudeCivil        = -6.0
+	AltitudeNautical     = -12.0
+	AltitudeAstronomical = -18.0
+)
+
+// HorizonDip returns how far, in degrees, the visible horizon lies below
+// the astronomical one for an observer elevation metres above it.
+func HorizonDip(elevation float64) float64 {
+	if elevation <= 0 {
+		return 0
+	}
+	return 0.0347 * math.Sqrt(elevation)
+}
+
+// SunriseAltitude returns the sun's altitude at the visible sunrise and
+// sunset for an observer at elevation metres.
+func SunriseAltitude(elevation float64) float64 {
+	return AltitudeSunrise - HorizonDip(elevation)
+}
+
+// SolarDay computes the sun's daily motion for one calendar date at a
+// location, following the NOAA solar calculator.
+type SolarDay struct {
+	Date      time.Time
+	Latitude  float64
+	Longitude float64
+
+	// Elevation in metres lowers the horizon for sunrise and sunset.
+	Elevation float64
+}
+
+func NewSolarDay(date time.Time, lat, lon float64) *SolarDay {
+	return &SolarDay{
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
+		Latitude:  lat,
+		Longitude: lon,
+	}
+}
+
+func (d *SolarDay) Noon() time.Time {
+	base := time.Date(d.Date.Year(), d.Date.Month(), d.Date.Day(), 0, 0, 0, 0, time.UTC)
+	noon := base.Add(minutes(720 - 4*d.Longitude))
+	for range 2 {
+		_, eqTime := sunCoords(julianDay(noon))
+		noon = base.Add(minutes(720 - 4*d.Longitude - eqTime))
+	}
+	return noon.In(d.Date.Location())
+}
+
+// NoonAltitude returns the sun's altitude at solar noon in degrees.
+func (d *SolarDay) NoonAltitude() float64 {
+	decl, _ := sunCoords(julianDay(d.Noon()))
+	return 90 - math.Abs(d.Latitude-degrees(decl))
+}
+
+// TimeAtAltitude returns when the sun crosses altitude (degrees) before
+// noon, or after noon when evening is set. It reports false when the sun
+// never reaches that altitude on this day.
+func (d *SolarDay) TimeAtAltitude(altitude float64, evening bool) (time.Time, bool) {
+	noon := d.Noon()
+	t := noon
+
+	for range 3 {
+		decl, _ := sunCoords(julianDay(t))
+		h, ok := hourAngle(d.Latitude, degrees(decl), altitude)
+		if !ok {
+			return time.Time{}, false
+		}
+		if !evening {
+			h = -h
+		}
+		t = noon.Add(minutes(4 * h))
+	}
+
+	return t, true
+}
+
+// Declination returns the sun's declination in degrees at t.
+func Declination(t time.Time) float64 {
+	decl, _ := sunCoords(julianDay(t))
+	return degrees(decl)
+}
+
+// ElevationShift returns how much earlier sunrise, or later sunset when
+// evening is set, the observer's elevation makes it.
+func (d *SolarDay) ElevationShift(evening bool) time.Duration {
+	flat, ok1 := d.TimeAtAltitude(AltitudeSunrise, evening)
+	raised, ok2 := d.TimeAtAltitude(SunriseAltitude(d.Elevation), evening)
+	if !ok1 || !ok2 {
+		return 0
+	}
+	if evening {
+		return raised.Sub(flat)
+	}
+	return flat.Sub(raised)
+}
+
+func (d *SolarDay) DayLength() time.Duration {
+	rise, ok1 := d.TimeAtAltitude(SunriseAltitude(d.Elevation), false)
+	set, ok2 := d.TimeAtAltitude(SunriseAltitude(d.Elevation), true)
+	if ok1 && ok2 {
+		return set.Sub(rise)
+	}
+	if d.NoonAltitude() > 0 {
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+func hourAngle(lat, decl, altitude float64) (float64, bool) {
+	φ, δ := radians(lat), radians(decl)
+	cosH := (math.Sin(radians(altitude)) - math.Sin(φ)*math.Sin(δ)) / (math.Cos(φ) * math.Cos(δ))
+	if cosH < -1 || cosH > 1 {
+		return 0, false
+	}
+	return degrees(math.Acos(cosH)), true
+}
+
+// sunCoords returns the apparent declination in radians and the equation
+// of time in minutes.
+func sunCoords(jd float64) (float64, float64) {
+	t := (jd - 2451545) / 36525
+
+	l0 := math.Mod(280.46646+t*(36000.76983+t*0.0003032), 360)
+	m := 357.52911 + t*(35999.05029-0.0001537*t)
+	e := 0.016708634 - t*(0.000042037+0.0000001267*t)
+
+	mr := radians(m)
+	c := math.Sin(mr)*(1.914602-t*(0.004817+0.000014*t)) +
+		math.Sin(2*mr)*(0.019993-0.000101*t) +
+		math.Sin(3*mr)*0.000289
+
+	omega := radians(125.04 - 1934.136*t)
+	lambda := radians(l0 + c - 0.00569 - 0.00478*math.Sin(omega))
+
+	eps0 := 23 + (26+(21.448-t*(46.815+t*(0.00059-t*0.001813)))/60)/60
+	eps := radians(eps0 + 0.00256*math.Cos(omega))
+
+	decl := math.Asin(math.Sin(eps) * math.Sin(lambda))
+
+	y := math.Pow(math.Tan(eps/2), 2)
+	l0r := radians(l0)
+	eqTime := 4 * degrees(y*math.Sin(2*l0r)-
+		2*e*math.Sin(mr)+
+		4*e*y*math.Sin(mr)*math.Cos(2*l0r)-
+		0.5*y*y*math.Sin(4*l0r)-
+		1.25*e*e*math.Sin(2*mr))
+
+	return decl, eqTime
+}
+
+func julianDay(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+func fromJulianDay(jd float64) time.Time {
+	return time.Unix(0, int64((jd-2440587.5)*float64(24*time.Hour))).UTC()
+}
+
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -1,11 +1,14 @@
 package cache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/zizouhuweidi/adhanctl/internal/api"
@@ -27,6 +30,31 @@ func New(ttl time.Duration) *Cache {
 	}
 }
 
+// WithProfile keeps a profile's entries apart from other profiles', since
+// profiles may differ in settings that aren't part of the cache key.
+func (c *Cache) WithProfile(name string) *Cache {
+	if name == "" {
+		return c
+	}
+	cp := *c
+	cp.Dir = filepath.Join(c.Dir, "profile-"+sanitize(name))
+	return &cp
+}
+
+// WithServer keeps entries fetched from an API server other than the
+// default apart, so switching to a mirror or back never serves the other
+// server's times.
+func (c *Cache) WithServer(baseURL string) *Cache {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" || baseURL == api.BaseURL {
+		return c
+	}
+	sum := sha256.Sum256([]byte(baseURL))
+	cp := *c
+	cp.Dir = filepath.Join(c.Dir, "server-"+hex.EncodeToString(sum[:6]))
+	return &cp
+}
+
 func xdgCacheDir() string {
 	if x := os.Getenv("XDG_CACHE_HOME"); x != "" {
 		return filepath.Join(x, CacheDirName)
@@ -42,6 +70,8 @@ func (c *Cache) filePath(params api.TimingsParams) string {
 	var key string
 	if params.Latitude != 0 && params.Longitude != 0 {
 		key = fmt.Sprintf("coords-%.4f-%.4f", params.Latitude, params.Longitude)
+	} else if params.Address != "" {
+		key = fmt.Sprintf("address-%s", sanitize(params.Address))
 	} else {
 		key = fmt.Sprintf("city-%s-%s", sanitize(params.City), sanitize(params.Country))
 	}
@@ -50,45 +80,47 @@ func (c *Cache) filePath(params api.TimingsParams) string {
 	return filepath.Join(c.Dir, filename)
 }
 
-func (c *Cache) Get(params api.TimingsParams) (*api.Response, bool) {
+// Get reads the entry for params into v, reporting whether there was a
+// fresh one.
+func (c *Cache) Get(params api.TimingsParams, v any) bool {
 	path := c.filePath(params)
 	if c.TTL <= 0 {
-		return nil, false
+		return false
 	}
 
 	info, err := os.Stat(path)
 	if err != nil {
-		return nil, false
+		return false
 	}
 
 	if time.Since(info.ModTime()) > c.TTL {
-		return nil, false
+		return false
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, false
+		return false
 	}
 
-	var resp api.Response
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := json.Unmarshal(data, v); err != nil {
 		c.Logger.Debug("cache unmarshal failed", "error", err)
-		return nil, false
+		return false
 	}
 
 	c.Logger.Debug("cache hit", "path", path)
-	return &resp, true
+	return true
 }
 
-func (c *Cache) Set(params api.TimingsParams, resp *api.Response) error {
+// Set stores v as JSON under params.
+func (c *Cache) Set(params api.TimingsParams, v any) error {
 	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
 		return fmt.Errorf("creating cache directory: %w", err)
 	}
 
 	path := c.filePath(params)
-	data, err := json.Marshal(resp)
+	data, err := json.Marshal(v)
 	if err != nil {
-		return fmt.Errorf("marshaling response: %w", err)
+		return fmt.Errorf("marshaling cache entry: %w", err)
 	}
 
 	if err := os.WriteFile(path, data, 0o644); err != nil {
@@ -99,15 +131,25 @@ func (c *Cache) Set(params api.TimingsParams, resp *api.Response) error {
 	return nil
 }
 
+// sanitize makes s safe as part of a file name. Characters that aren't
+// kept, such as non-Latin letters, are replaced by a hash of all of s so
+// different names never share an entry.
 func sanitize(s string) string {
 	result := make([]rune, 0, len(s))
+	dropped := false
 	for _, r := range s {
 		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
 			result = append(result, r)
 		} else if r == ' ' {
 			result = append(result, '_')
+		} else {
+			dropped = true
 		}
 	}
+	if dropped {
+		sum := sha256.Sum256([]byte(s))
+		result = append(result, []rune("~"+hex.EncodeToString(sum[:6]))...)
+	}
 	if len(result) == 0 {
 		return "default"
 	}
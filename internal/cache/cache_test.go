@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/api"
+)
+
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+	c := New(time.Hour)
+	c.Dir = t.TempDir()
+	return c
+}
+
+func TestServersKeptApart(t *testing.T) {
+	c := newTestCache(t)
+	params := api.TimingsParams{City: "Amman", Country: "Jordan", Date: time.Now()}
+
+	if err := c.WithServer(api.BaseURL).Set(params, "aladhan"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if !c.WithServer(api.BaseURL+"/").Get(params, &got) || got != "aladhan" {
+		t.Errorf("default server: got %q, want its own entry", got)
+	}
+	if c.WithServer("https://prayer.example.org/v1").Get(params, &got) {
+		t.Errorf("mirror got the default server's entry %q", got)
+	}
+}
+
+func TestNonLatinNamesKeptApart(t *testing.T) {
+	c := newTestCache(t)
+	date := time.Now()
+	cities := []string{"عمان", "القاهرة", "Москва", "Zürich", "Zurich"}
+
+	for _, city := range cities {
+		if err := c.Set(api.TimingsParams{City: city, Date: date}, city); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, city := range cities {
+		var got string
+		if !c.Get(api.TimingsParams{City: city, Date: date}, &got) || got != city {
+			t.Errorf("%s: got the entry for %q", city, got)
+		}
+	}
+}
@@ -2,14 +2,19 @@ package config
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/api"
 )
 
 const (
@@ -17,26 +22,128 @@ const (
 	ConfigFileName = "config"
 )
 
+// ErrUnknownKey is returned for keys this version doesn't know. They are
+// kept in the file but otherwise ignored.
+var ErrUnknownKey = errors.New("unknown key")
+
+// sources are the values the source key accepts, one for each provider of
+// prayer times.
+var sources = []string{"aladhan", "local", "timetable"}
+
 type Config struct {
-	City      string
-	Country   string
-	Latitude  float64
-	Longitude float64
-	Method    int
-	School    int
-	AmPm      bool
-	Arabic    bool
-	Short     bool
-	CacheSecs int
-	Interval  time.Duration
+	City       string
+	Country    string
+	Latitude   float64
+	Longitude  float64
+	Elevation  float64
+	Method     int
+	School     int
+	Provider   string
+	Timetable  string
+	AmPm       bool
+	Arabic     bool
+	Short      bool
+	CacheSecs  int
+	Interval   time.Duration
+	IshaEnd    string
+	WindowWarn time.Duration
+
+	MakruhSunrise time.Duration
+	MakruhZawal   time.Duration
+	MakruhSunset  time.Duration
+
+	JumuahKhutbah  string
+	JumuahIqamah   string
+	JumuahReminder time.Duration
+	KahfReminder   string
+
+	Ramadan        string
+	SuhoorWarnings []time.Duration
+	Taraweeh       time.Duration
+
+	EventNotify      bool
+	FastingReminders bool
+
+	WaybarQibla bool
+
+	Travel       bool
+	LocationFile string
+	GPSD         string
+
+	Musafir         string
+	MusafirDistance float64
+	Combine         bool
+	HomeProfile     string
+
+	APIURL     string
+	APITimeout time.Duration
+	UserAgent  string
+	Proxy      string
+
+	// Profile is the default profile named in the file. Active is the
+	// profile applied by WithProfile, if any.
+	Profile  string
+	Active   string
+	Profiles map[string][]Setting
+
+	profileOrder []string
+	base         *Config
+	sources      map[string]string
+}
+
+type Setting struct {
+	Key   string
+	Value string
 }
 
 func Default() *Config {
 	return &Config{
-		Method:    3,
-		School:    0,
-		CacheSecs: 3 * 3600,
-		Interval:  time.Minute,
+		Method:     3,
+		School:     0,
+		Provider:   "aladhan",
+		CacheSecs:  3 * 3600,
+		Interval:   time.Minute,
+		IshaEnd:    "midnight",
+		WindowWarn: 15 * time.Minute,
+
+		MakruhSunrise: 15 * time.Minute,
+		MakruhZawal:   10 * time.Minute,
+		MakruhSunset:  20 * time.Minute,
+
+		Ramadan:        "auto",
+		SuhoorWarnings: []time.Duration{30 * time.Minute, 10 * time.Minute},
+
+		EventNotify:      true,
+		FastingReminders: true,
+
+		Musafir:         "off",
+		MusafirDistance: 81,
+		Combine:         true,
+
+		APIURL:     api.BaseURL,
+		APITimeout: api.DefaultTimeout,
+		UserAgent:  api.UserAgent,
+
+		Profiles: make(map[string][]Setting),
+		sources:  make(map[string]string),
+	}
+}
+
+// locationKeys together describe one place. A layer that sets any of them
+// replaces the location from the layers below instead of mixing with it,
+// since coordinates would otherwise win over a city set on top of them.
+var locationKeys = []string{"city", "country", "latitude", "longitude"}
+
+func isLocationKey(key string) bool {
+	return slices.Contains(locationKeys, key)
+}
+
+// clearLocation forgets the location set so far, and where it came from.
+func (c *Config) clearLocation() {
+	c.City, c.Country = "", ""
+	c.Latitude, c.Longitude = 0, 0
+	for _, key := range locationKeys {
+		delete(c.sources, key)
 	}
 }
 
@@ -55,7 +162,28 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// EnvPrefix starts the environment variables that override config keys,
+// e.g. ADHANCTL_CITY for city.
+const EnvPrefix = "ADHANCTL_"
+
+// pathOverride is the config file chosen with --config.
+var pathOverride string
+
+// SetPath makes ConfigPath return path, for the --config flag.
+func SetPath(path string) {
+	pathOverride = path
+}
+
+// ConfigPath returns the user config file: --config, then $ADHANCTL_CONFIG,
+// then the XDG location.
 func ConfigPath() string {
+	if pathOverride != "" {
+		return pathOverride
+	}
+	if p := os.Getenv(EnvPrefix + "CONFIG"); p != "" {
+		return p
+	}
+
 	var base string
 	if x := os.Getenv("XDG_CONFIG_HOME"); x != "" {
 		base = filepath.Join(x, ConfigDirName)
@@ -69,241 +197,787 @@ func ConfigPath() string {
 	return filepath.Join(base, ConfigFileName)
 }
 
+// SystemConfigPath returns the first system-wide config file found in
+// $XDG_CONFIG_DIRS (default /etc/xdg), or the default location if none
+// exists.
+func SystemConfigPath() string {
+	dirs := os.Getenv("XDG_CONFIG_DIRS")
+	if dirs == "" {
+		dirs = "/etc/xdg"
+	}
+
+	var first string
+	for _, dir := range filepath.SplitList(dirs) {
+		if dir == "" {
+			continue
+		}
+		p := filepath.Join(dir, ConfigDirName, ConfigFileName)
+		if first == "" {
+			first = p
+		}
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return first
+}
+
+// Load builds the config from its layers, each overriding the one before:
+// defaults, the system config, the user config and ADHANCTL_* variables.
+// Command-line flags are applied on top by the caller.
 func Load() (*Config, error) {
+	cfg, err := Base()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.loadFile(ConfigPath(), "user"); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.applyEnv(true); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Base returns the layers the user config file goes over: the defaults and
+// the system config.
+func Base() (*Config, error) {
 	cfg := Default()
-	path := ConfigPath()
+	if err := cfg.loadFile(SystemConfigPath(), "system"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
-	data, err := os.ReadFile(path)
+// loadFile applies the config file at path as the layer named source.
+func (c *Config) loadFile(path, source string) error {
+	f, err := ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil
+		return err
+	}
+
+	// Older formats are parsed as they are, so problems cite the lines
+	// the user sees. Loading never writes, so read-only config files
+	// work; commands that change the file upgrade it on disk through
+	// OpenFile.
+	return checkProblems(path, c.parse(f.Bytes(), source+" "+path))
+}
+
+func checkProblems(path string, problems []Problem) error {
+	var errs []Problem
+	for _, p := range problems {
+		if p.Warning {
+			slog.Warn("config", "path", path, "line", p.Line, "problem", p.Message)
+			continue
 		}
-		return nil, fmt.Errorf("reading config: %w", err)
+		errs = append(errs, p)
+	}
+	if len(errs) > 0 {
+		return &ParseError{Path: path, Problems: errs}
+	}
+	return nil
+}
+
+// applyEnv applies ADHANCTL_* variables, which override both config files
+// and the active profile. Unknown variables are warned about once, on the
+// first pass.
+func (c *Config) applyEnv(warnUnknown bool) error {
+	type variable struct{ name, key, value string }
+	var vars []variable
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		key, ok := strings.CutPrefix(name, EnvPrefix)
+		if !ok || key == "CONFIG" {
+			continue
+		}
+		vars = append(vars, variable{name, strings.ToLower(key), value})
+	}
+
+	if slices.ContainsFunc(vars, func(v variable) bool { return isLocationKey(v.key) }) {
+		c.clearLocation()
+	}
+
+	var errs []string
+	for _, v := range vars {
+		name, key, value := v.name, v.key, v.value
+
+		if key == "profile" {
+			c.Profile = value
+			c.sources[key] = "env " + name
+			continue
+		}
+
+		err := c.set(key, value)
+		switch {
+		case errors.Is(err, ErrUnknownKey):
+			if warnUnknown {
+				slog.Warn("ignoring unknown environment variable", "name", name)
+			}
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		default:
+			c.sources[key] = "env " + name
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid environment:\n  %s", strings.Join(errs, "\n  "))
 	}
+	return nil
+}
+
+// Source reports which layer set key: "default", "system PATH",
+// "user PATH", "profile NAME", "env NAME" or "flag".
+func (c *Config) Source(key string) string {
+	if src, ok := c.sources[key]; ok {
+		return src
+	}
+	return "default"
+}
+
+// Problem is one issue found while parsing the config file. Warnings, such
+// as unknown keys, don't stop the file from loading.
+type Problem struct {
+	Line    int
+	Message string
+	Warning bool
+}
+
+func (p Problem) String() string {
+	kind := "error"
+	if p.Warning {
+		kind = "warning"
+	}
+	return fmt.Sprintf("line %d: %s: %s", p.Line, kind, p.Message)
+}
+
+// ParseError reports every error in the config file at once.
+type ParseError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid config %s:", e.Path)
+	for _, p := range e.Problems {
+		fmt.Fprintf(&sb, "\n  line %d: %s", p.Line, p.Message)
+	}
+	return sb.String()
+}
+
+// Parse reads a config file's contents, collecting every problem with its
+// line number instead of stopping at the first. Lines with errors are
+// skipped, so the returned config holds only valid settings.
+func Parse(data []byte) (*Config, []Problem) {
+	cfg := Default()
+	return cfg, cfg.parse(data, "")
+}
+
+// ParseUser parses a user config file's contents like Parse, but over the
+// same base Load uses, so the file may select a profile the system config
+// defines.
+func ParseUser(data []byte) (*Config, []Problem, error) {
+	cfg, err := Base()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, cfg.parse(data, ""), nil
+}
+
+// parse applies a config file on top of c, recording source as the origin
+// of each value it sets.
+func (c *Config) parse(data []byte, source string) []Problem {
+	cfg := c
+	var problems []Problem
+
+	report := func(line int, warning bool, format string, args ...any) {
+		problems = append(problems, Problem{Line: line, Message: fmt.Sprintf(format, args...), Warning: warning})
+	}
+
+	profile := ""
+	group := ""
+	skip := false
+	located := false
+	version := 1
+	profileLine := 0
+	seen := make(map[string]int)
 
 	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-	for scanner.Scan() {
+	for n := 1; scanner.Scan(); n++ {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
+		if name, ok := sectionName(line); ok {
+			profile, group, skip = "", "", false
+			switch p, isProfile := strings.CutPrefix(name, "profile."); {
+			case isProfile && p != "":
+				profile = p
+				cfg.AddProfile(profile, cfg.Profiles[profile])
+			case isSection(name):
+				group = name
+			default:
+				skip = true
+				report(n, true, "unknown section [%s], its settings are ignored", name)
+			}
+			continue
+		}
+		if skip {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			report(n, false, "expected key = value, got %q", line)
 			continue
 		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		switch key {
-		case "city":
-			cfg.City = value
-		case "country":
-			cfg.Country = value
-		case "latitude":
-			cfg.Latitude, _ = strconv.ParseFloat(value, 64)
-		case "longitude":
-			cfg.Longitude, _ = strconv.ParseFloat(value, 64)
-		case "method":
-			cfg.Method, _ = strconv.Atoi(value)
-		case "school":
-			cfg.School, _ = strconv.Atoi(value)
-		case "ampm":
-			cfg.AmPm = value == "true"
-		case "arabic":
-			cfg.Arabic = value == "true"
-		case "short":
-			cfg.Short = value == "true"
-		case "cache_secs":
-			cfg.CacheSecs, _ = strconv.Atoi(value)
-		case "interval":
-			d, err := time.ParseDuration(value)
-			if err == nil {
-				cfg.Interval = d
+		id := profile + "." + key
+		if prev, dup := seen[id]; dup {
+			report(n, true, "%s is already set on line %d, this value wins", key, prev)
+		}
+		seen[id] = n
+
+		if want := SectionFor(key); profile == "" && want != group {
+			known := want != "" || key == "profile" || key == "version"
+			if known && (group != "" || version >= 2) {
+				where := "at the top"
+				if want != "" {
+					where = "in [" + want + "]"
+				}
+				report(n, true, "%s belongs %s", key, where)
 			}
 		}
+
+		switch {
+		case profile != "":
+			if key == "profile" || key == "version" {
+				report(n, false, "profile %s: %s can only be set at the top level", profile, key)
+				continue
+			}
+			if err := ValidateSetting(key, value); err != nil {
+				report(n, errors.Is(err, ErrUnknownKey), "profile %s: %v", profile, err)
+				continue
+			}
+			cfg.Profiles[profile] = append(cfg.Profiles[profile], Setting{Key: key, Value: value})
+		case key == "version":
+			v, err := strconv.Atoi(value)
+			if err != nil || v < 1 || v > CurrentVersion {
+				report(n, false, "version: expected 1 to %d, got %q", CurrentVersion, value)
+				continue
+			}
+			version = v
+		case key == "profile":
+			cfg.Profile = value
+			cfg.sources[key] = source
+			profileLine = n
+		default:
+			if isLocationKey(key) && !located {
+				if err := ValidateSetting(key, value); err == nil {
+					cfg.clearLocation()
+					located = true
+				}
+			}
+			if err := cfg.set(key, value); err != nil {
+				report(n, errors.Is(err, ErrUnknownKey), "%v", err)
+				continue
+			}
+			cfg.sources[key] = source
+		}
 	}
 
-	return cfg, nil
+	if cfg.Profile != "" {
+		if _, ok := cfg.Profiles[cfg.Profile]; !ok {
+			report(profileLine, false, "profile: no [profile.%s] section", cfg.Profile)
+		}
+	}
+	if cfg.HomeProfile != "" {
+		if _, ok := cfg.Profiles[cfg.HomeProfile]; !ok {
+			report(seen[".home_profile"], false, "home_profile: no [profile.%s] section", cfg.HomeProfile)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems
 }
 
-func (c *Config) Save() error {
-	path := ConfigPath()
-	dir := filepath.Dir(path)
+// set applies one key from the file. It leaves the config unchanged and
+// returns an error when the key is unknown or the value doesn't parse.
+func (c *Config) set(key, value string) error {
+	var err error
+	switch key {
+	case "city":
+		c.City = value
+	case "country":
+		c.Country = value
+	case "latitude":
+		err = setRange(&c.Latitude, value, -90, 90)
+	case "longitude":
+		err = setRange(&c.Longitude, value, -180, 180)
+	case "elevation":
+		err = setRange(&c.Elevation, value, 0, 9000)
+	case "method":
+		err = setKnown(&c.Method, value, CalculationMethods)
+	case "school":
+		err = setKnown(&c.School, value, Schools)
+	case "source":
+		err = setChoice(&c.Provider, value, sources...)
+	case "timetable":
+		c.Timetable = value
+	case "cache_secs":
+		err = setInt(&c.CacheSecs, value)
+		if err == nil && c.CacheSecs < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+	case "ampm":
+		err = setBool(&c.AmPm, value)
+	case "arabic":
+		err = setBool(&c.Arabic, value)
+	case "short":
+		err = setBool(&c.Short, value)
+	case "interval":
+		err = setDuration(&c.Interval, value)
+	case "isha_end":
+		err = setChoice(&c.IshaEnd, value, "midnight", "fajr")
+	case "window_warn":
+		err = setDuration(&c.WindowWarn, value)
+	case "makruh_sunrise":
+		err = setDuration(&c.MakruhSunrise, value)
+	case "makruh_zawal":
+		err = setDuration(&c.MakruhZawal, value)
+	case "makruh_sunset":
+		err = setDuration(&c.MakruhSunset, value)
+	case "jumuah_khutbah":
+		err = setClock(&c.JumuahKhutbah, value)
+	case "jumuah_iqamah":
+		err = setClock(&c.JumuahIqamah, value)
+	case "jumuah_reminder":
+		err = setDuration(&c.JumuahReminder, value)
+	case "kahf_reminder":
+		err = setClock(&c.KahfReminder, value)
+	case "ramadan":
+		err = setChoice(&c.Ramadan, value, "auto", "on", "off")
+	case "suhoor_warnings":
+		ds, perr := parseDurations(value)
+		if perr != nil {
+			err = fmt.Errorf("expected durations like 30m,10m")
+		} else {
+			c.SuhoorWarnings = ds
+		}
+	case "taraweeh":
+		err = setDuration(&c.Taraweeh, value)
+	case "event_notify":
+		err = setBool(&c.EventNotify, value)
+	case "fasting_reminders":
+		err = setBool(&c.FastingReminders, value)
+	case "waybar_qibla":
+		err = setBool(&c.WaybarQibla, value)
+	case "travel":
+		err = setBool(&c.Travel, value)
+	case "location_file":
+		c.LocationFile = value
+	case "gpsd":
+		c.GPSD = value
+	case "musafir":
+		err = setChoice(&c.Musafir, value, "auto", "on", "off")
+	case "musafir_distance":
+		err = setRange(&c.MusafirDistance, value, 0, 20000)
+	case "combine":
+		err = setBool(&c.Combine, value)
+	case "home_profile":
+		c.HomeProfile = value
+	case "api_url":
+		err = setURL(&c.APIURL, value, "http", "https")
+	case "api_timeout":
+		err = setDuration(&c.APITimeout, value)
+		if err == nil && c.APITimeout <= 0 {
+			err = fmt.Errorf("must be positive")
+		}
+	case "user_agent":
+		c.UserAgent = value
+	case "proxy":
+		err = setURL(&c.Proxy, value, "http", "https", "socks5")
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownKey, key)
+	}
 
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating config directory: %w", err)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
 	}
+	return nil
+}
 
-	var sb strings.Builder
-	sb.WriteString("# adhanctl configuration\n")
-	sb.WriteString("# Generated by 'adhanctl config init'\n\n")
+func setFloat(dst *float64, value string) error {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("expected a number, got %q", value)
+	}
+	*dst = v
+	return nil
+}
 
-	if c.City != "" {
-		fmt.Fprintf(&sb, "city = %s\n", c.City)
+func setRange(dst *float64, value string, lo, hi float64) error {
+	var v float64
+	if err := setFloat(&v, value); err != nil {
+		return err
 	}
-	if c.Country != "" {
-		fmt.Fprintf(&sb, "country = %s\n", c.Country)
+	if v < lo || v > hi {
+		return fmt.Errorf("%g is out of range (%g to %g)", v, lo, hi)
 	}
-	if c.Latitude != 0 {
-		fmt.Fprintf(&sb, "latitude = %.6f\n", c.Latitude)
+	*dst = v
+	return nil
+}
+
+func setKnown(dst *int, value string, known map[int]string) error {
+	var v int
+	if err := setInt(&v, value); err != nil {
+		return err
 	}
-	if c.Longitude != 0 {
-		fmt.Fprintf(&sb, "longitude = %.6f\n", c.Longitude)
+	if _, ok := known[v]; !ok {
+		ids := make([]int, 0, len(known))
+		for id := range known {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		return fmt.Errorf("%d is not one of %s", v, strings.Trim(fmt.Sprint(ids), "[]"))
 	}
-	fmt.Fprintf(&sb, "method = %d\n", c.Method)
-	fmt.Fprintf(&sb, "school = %d\n", c.School)
-	fmt.Fprintf(&sb, "ampm = %t\n", c.AmPm)
-	fmt.Fprintf(&sb, "arabic = %t\n", c.Arabic)
-	fmt.Fprintf(&sb, "short = %t\n", c.Short)
-	fmt.Fprintf(&sb, "cache_secs = %d\n", c.CacheSecs)
-	fmt.Fprintf(&sb, "interval = %s\n", c.Interval)
+	*dst = v
+	return nil
+}
 
-	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
-		return fmt.Errorf("writing config: %w", err)
+func setInt(dst *int, value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("expected a whole number, got %q", value)
 	}
+	*dst = v
+	return nil
+}
 
+func setBool(dst *bool, value string) error {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("expected true or false, got %q", value)
+	}
+	*dst = v
 	return nil
 }
 
-var CalculationMethods = map[int]string{
-	1:  "University of Islamic Sciences, Karachi",
-	2:  "Islamic Society of North America (ISNA)",
-	3:  "Muslim World League",
-	4:  "Umm Al-Qura University, Makkah",
-	5:  "Egyptian General Authority of Survey",
-	8:  "Gulf Region",
-	9:  "Kuwait",
-	10: "Qatar",
-	11: "Majlis Ugama Islam Singapura, Singapore",
-	12: "Union Organization Islamic de France",
-	13: "Diyanet İşleri Başkanlığı, Turkey",
-	14: "Spiritual Administration of Muslims of Russia",
-	15: "Moonsighting Committee Worldwide",
-	16: "Dubai",
-	17: "Jabatan Kemajuan Islam Malaysia (JAKIM)",
-	18: "Tunisia",
-	19: "Algeria",
-	20: "Kementerian Agama Republik Indonesia",
-	21: "Morocco",
-	22: "Comunidade Islamica de Lisboa",
-	23: "Ministry of Awqaf, Islamic Affairs and Holy Places, Jordan",
+func setDuration(dst *time.Duration, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("expected a duration like 15m, got %q", value)
+	}
+	*dst = d
+	return nil
 }
 
-var Schools = map[int]string{
-	0: "Shafi",
-	1: "Hanafi",
+func setChoice(dst *string, value string, choices ...string) error {
+	for _, c := range choices {
+		if strings.EqualFold(value, c) {
+			*dst = c
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s, got %q", strings.Join(choices, ", "), value)
 }
 
-type Interactor interface {
-	Prompt(prompt string) (string, error)
-	PromptDefault(prompt, defaultValue string) (string, error)
-	PromptChoice(prompt string, choices map[int]string) (int, error)
+// setURL accepts an absolute URL with one of schemes, or an empty value.
+func setURL(dst *string, value string, schemes ...string) error {
+	if value != "" {
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+			return fmt.Errorf("expected a %s URL, got %q", strings.Join(schemes, "/"), value)
+		}
+	}
+	*dst = value
+	return nil
 }
 
-type StdioInteractor struct {
-	Logger *slog.Logger
+func setClock(dst *string, value string) error {
+	if value != "" {
+		if _, err := time.Parse("15:04", value); err != nil {
+			return fmt.Errorf("expected HH:MM, got %q", value)
+		}
+	}
+	*dst = value
+	return nil
 }
 
-func (s *StdioInteractor) Prompt(prompt string) (string, error) {
-	fmt.Printf("%s: ", prompt)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return "", err
+// ValidateSetting checks a single key and value with the same rules Load
+// applies to the file.
+func ValidateSetting(key, value string) error {
+	if key == "profile" {
+		return nil
 	}
-	return strings.TrimSpace(input), nil
+	return Default().set(key, value)
 }
 
-func (s *StdioInteractor) PromptDefault(prompt, defaultValue string) (string, error) {
-	if defaultValue != "" {
-		fmt.Printf("%s [%s]: ", prompt, defaultValue)
-	} else {
-		fmt.Printf("%s: ", prompt)
+// WithProfile returns a copy of the config with the named profile's
+// settings applied over the top-level ones.
+func (c *Config) WithProfile(name string) (*Config, error) {
+	settings, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
 	}
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return "", err
+
+	cp := *c
+	cp.Active = name
+	cp.base = c.top()
+	cp.sources = make(map[string]string, len(c.sources))
+	for k, v := range c.sources {
+		cp.sources[k] = v
+	}
+
+	if slices.ContainsFunc(settings, func(s Setting) bool { return isLocationKey(s.Key) }) {
+		cp.clearLocation()
 	}
-	input = strings.TrimSpace(input)
-	if input == "" {
-		return defaultValue, nil
+	for _, s := range settings {
+		if err := cp.set(s.Key, s.Value); err != nil {
+			slog.Debug("ignoring profile setting", "profile", name, "error", err)
+			continue
+		}
+		cp.sources[s.Key] = "profile " + name
 	}
-	return input, nil
+
+	// Environment variables outrank the config file, profiles included.
+	if err := cp.applyEnv(false); err != nil {
+		return nil, err
+	}
+	return &cp, nil
 }
 
-func (s *StdioInteractor) PromptChoice(prompt string, choices map[int]string) (int, error) {
-	fmt.Printf("%s:\n", prompt)
-	keys := make([]int, 0, len(choices))
-	for k := range choices {
-		keys = append(keys, k)
+// WithFlags returns a copy of the config with settings given on the
+// command line applied over every other layer. Invalid values are left
+// out and reported together; the copy holds the valid ones.
+func (c *Config) WithFlags(settings []Setting) (*Config, error) {
+	cp := *c
+	cp.sources = make(map[string]string, len(c.sources))
+	for k, v := range c.sources {
+		cp.sources[k] = v
 	}
-	sort.Ints(keys)
-	for _, k := range keys {
-		fmt.Printf("  %2d: %s\n", k, choices[k])
+
+	if slices.ContainsFunc(settings, func(s Setting) bool { return isLocationKey(s.Key) }) {
+		cp.clearLocation()
 	}
-	fmt.Print("Enter number: ")
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return 0, err
+
+	var errs []string
+	for _, s := range settings {
+		if err := cp.set(s.Key, s.Value); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		cp.sources[s.Key] = "flag"
 	}
-	input = strings.TrimSpace(input)
-	val, err := strconv.Atoi(input)
-	if err != nil {
-		return 0, fmt.Errorf("invalid number: %s", input)
+
+	if len(errs) > 0 {
+		return &cp, fmt.Errorf("invalid flags:\n  %s", strings.Join(errs, "\n  "))
 	}
-	if _, ok := choices[val]; !ok {
-		return 0, fmt.Errorf("invalid choice: %d", val)
+	return &cp, nil
+}
+
+// Home returns the config for the home location: the home_profile if set,
+// otherwise the top-level settings, whichever profile is active.
+func (c *Config) Home() (*Config, error) {
+	top := c.top()
+	if c.HomeProfile == "" {
+		return top, nil
 	}
-	return val, nil
+	return top.WithProfile(c.HomeProfile)
 }
 
-func RunConfigInit(interactor Interactor) (*Config, error) {
-	cfg := Default()
+func (c *Config) top() *Config {
+	if c.base != nil {
+		return c.base
+	}
+	return c
+}
 
-	fmt.Println("\nadhanctl - First Run Setup")
-	fmt.Println("==========================")
+func (c *Config) ProfileNames() []string {
+	return append([]string(nil), c.profileOrder...)
+}
 
-	city, err := interactor.Prompt("City")
-	if err != nil {
-		return nil, err
+func (c *Config) AddProfile(name string, settings []Setting) {
+	if _, ok := c.Profiles[name]; !ok {
+		c.profileOrder = append(c.profileOrder, name)
 	}
-	cfg.City = city
+	c.Profiles[name] = settings
+}
 
-	country, err := interactor.Prompt("Country")
-	if err != nil {
-		return nil, err
+// Settings returns every key with its current value as it would be written
+// to the file. Unset optional values are empty.
+func (c *Config) Settings() []Setting {
+	optFloat := func(v float64, format string) string {
+		if v == 0 {
+			return ""
+		}
+		return fmt.Sprintf(format, v)
 	}
-	cfg.Country = country
 
-	fmt.Println()
-	method, err := interactor.PromptChoice("Calculation Method", CalculationMethods)
-	if err != nil {
-		return nil, err
+	return []Setting{
+		{"city", c.City},
+		{"country", c.Country},
+		{"latitude", optFloat(c.Latitude, "%.6f")},
+		{"longitude", optFloat(c.Longitude, "%.6f")},
+		{"elevation", optFloat(c.Elevation, "%g")},
+		{"method", strconv.Itoa(c.Method)},
+		{"school", strconv.Itoa(c.School)},
+		{"source", c.Provider},
+		{"timetable", c.Timetable},
+		{"ampm", strconv.FormatBool(c.AmPm)},
+		{"arabic", strconv.FormatBool(c.Arabic)},
+		{"short", strconv.FormatBool(c.Short)},
+		{"cache_secs", strconv.Itoa(c.CacheSecs)},
+		{"interval", c.Interval.String()},
+		{"isha_end", c.IshaEnd},
+		{"window_warn", c.WindowWarn.String()},
+		{"makruh_sunrise", c.MakruhSunrise.String()},
+		{"makruh_zawal", c.MakruhZawal.String()},
+		{"makruh_sunset", c.MakruhSunset.String()},
+		{"jumuah_khutbah", c.JumuahKhutbah},
+		{"jumuah_iqamah", c.JumuahIqamah},
+		{"jumuah_reminder", c.JumuahReminder.String()},
+		{"kahf_reminder", c.KahfReminder},
+		{"ramadan", c.Ramadan},
+		{"suhoor_warnings", formatDurations(c.SuhoorWarnings)},
+		{"taraweeh", c.Taraweeh.String()},
+		{"event_notify", strconv.FormatBool(c.EventNotify)},
+		{"fasting_reminders", strconv.FormatBool(c.FastingReminders)},
+		{"waybar_qibla", strconv.FormatBool(c.WaybarQibla)},
+		{"travel", strconv.FormatBool(c.Travel)},
+		{"location_file", c.LocationFile},
+		{"gpsd", c.GPSD},
+		{"musafir", c.Musafir},
+		{"musafir_distance", fmt.Sprintf("%g", c.MusafirDistance)},
+		{"combine", strconv.FormatBool(c.Combine)},
+		{"home_profile", c.HomeProfile},
+		{"api_url", c.APIURL},
+		{"api_timeout", c.APITimeout.String()},
+		{"user_agent", c.UserAgent},
+		{"proxy", c.Proxy},
 	}
-	cfg.Method = method
+}
 
-	fmt.Println()
-	school, err := interactor.PromptChoice("Asr Calculation School", Schools)
-	if err != nil {
-		return nil, err
+// Get returns the current value of a key, including defaults.
+func (c *Config) Get(key string) (string, error) {
+	if key == "profile" {
+		return c.Profile, nil
 	}
-	cfg.School = school
+	for _, st := range c.Settings() {
+		if st.Key == key {
+			return st.Value, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownKey, key)
+}
 
-	ampm, err := interactor.PromptDefault("Use 12-hour format (AM/PM)", "false")
+// Save writes the config into the user config file. Keys in answered, and
+// those whose value differs from base, are set; the rest are removed, as
+// base already gives them. Only the lines for those keys are touched, so
+// comments and unknown keys survive.
+func (c *Config) Save(base *Config, answered map[string]string) error {
+	f, err := OpenFile(ConfigPath())
 	if err != nil {
-		return nil, err
+		return err
 	}
-	cfg.AmPm = ampm == "true" || ampm == "yes" || ampm == "y"
 
-	arabic, err := interactor.PromptDefault("Display Hijri month/weekday in Arabic", "false")
-	if err != nil {
-		return nil, err
+	if len(f.lines) <= 1 {
+		f.lines = []string{
+			"# adhanctl configuration",
+			"# Generated by 'adhanctl config init'",
+			"",
+			fmt.Sprintf("version = %d", CurrentVersion),
+		}
 	}
-	cfg.Arabic = arabic == "true" || arabic == "yes" || arabic == "y"
 
-	return cfg, nil
+	if c.Profile != "" {
+		f.Set("", "profile", c.Profile)
+	} else {
+		f.Unset("", "profile")
+	}
+
+	below := make(map[string]string)
+	for _, st := range base.Settings() {
+		below[st.Key] = st.Value
+	}
+	for _, st := range c.Settings() {
+		section := SectionFor(st.Key)
+		_, keep := answered[st.Key]
+		if st.Value == "" || (!keep && st.Value == below[st.Key]) {
+			f.Unset(section, st.Key)
+			continue
+		}
+		f.Set(section, st.Key, st.Value)
+	}
+
+	for _, name := range c.profileOrder {
+		for _, s := range c.Profiles[name] {
+			f.Set("profile."+name, s.Key, s.Value)
+		}
+	}
+
+	return f.Write()
+}
+
+func parseDurations(value string) ([]time.Duration, error) {
+	var ds []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
+func formatDurations(ds []time.Duration) string {
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = d.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+var CalculationMethods = map[int]string{
+	0:  "Shia Ithna-Ashari, Leva Institute, Qum",
+	1:  "University of Islamic Sciences, Karachi",
+	2:  "Islamic Society of North America (ISNA)",
+	3:  "Muslim World League",
+	4:  "Umm Al-Qura University, Makkah",
+	5:  "Egyptian General Authority of Survey",
+	7:  "Institute of Geophysics, University of Tehran",
+	8:  "Gulf Region",
+	9:  "Kuwait",
+	10: "Qatar",
+	11: "Majlis Ugama Islam Singapura, Singapore",
+	12: "Union Organization Islamic de France",
+	13: "Diyanet İşleri Başkanlığı, Turkey",
+	14: "Spiritual Administration of Muslims of Russia",
+	15: "Moonsighting Committee Worldwide",
+	16: "Dubai",
+	17: "Jabatan Kemajuan Islam Malaysia (JAKIM)",
+	18: "Tunisia",
+	19: "Algeria",
+	20: "Kementerian Agama Republik Indonesia",
+	21: "Morocco",
+	22: "Comunidade Islamica de Lisboa",
+	23: "Ministry of Awqaf, Islamic Affairs and Holy Places, Jordan",
+}
+
+var Schools = map[int]string{
+	0: "Shafi",
+	1: "Hanafi",
 }
@@ -0,0 +1,400 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file at dir/adhanctl/config.
+func writeConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	path := filepath.Join(dir, ConfigDirName, ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	tests := []struct {
+		name    string
+		system  string
+		user    string
+		profile string
+		env     map[string]string
+		flags   []Setting
+
+		// want maps keys to their value and the kind of layer that set
+		// them: "default", "system", "user", "profile", "env" or "flag".
+		want map[string][2]string
+	}{
+		{
+			name: "default",
+			want: map[string][2]string{"method": {"3", "default"}},
+		},
+		{
+			name:   "system over default",
+			system: "version = 2\n\n[location]\nmethod = 2\n",
+			want:   map[string][2]string{"method": {"2", "system"}},
+		},
+		{
+			name:   "user over system",
+			system: "version = 2\n\n[location]\nmethod = 2\nschool = 1\n",
+			user:   "version = 2\n\n[location]\nmethod = 4\n",
+			want: map[string][2]string{
+				"method": {"4", "user"},
+				"school": {"1", "system"},
+			},
+		},
+		{
+			name:    "profile over user",
+			user:    "version = 2\n\n[location]\nmethod = 4\n\n[profile.work]\nmethod = 23\n",
+			profile: "work",
+			want:    map[string][2]string{"method": {"23", "profile"}},
+		},
+		{
+			name:    "env over profile",
+			user:    "version = 2\n\n[location]\nmethod = 4\n\n[profile.work]\nmethod = 23\n",
+			profile: "work",
+			env:     map[string]string{"ADHANCTL_METHOD": "5"},
+			want:    map[string][2]string{"method": {"5", "env"}},
+		},
+		{
+			name:  "flag over env",
+			user:  "version = 2\n\n[location]\nmethod = 4\n",
+			env:   map[string]string{"ADHANCTL_METHOD": "5"},
+			flags: []Setting{{"method", "1"}},
+			want:  map[string][2]string{"method": {"1", "flag"}},
+		},
+		{
+			name:   "user coordinates replace system city",
+			system: "version = 2\n\n[location]\ncity = London\ncountry = United Kingdom\n",
+			user:   "version = 2\n\n[location]\nlatitude = 30.0444\nlongitude = 31.2357\n",
+			want: map[string][2]string{
+				"city":     {"", "default"},
+				"country":  {"", "default"},
+				"latitude": {"30.044400", "user"},
+			},
+		},
+		{
+			name:    "profile city replaces user coordinates",
+			user:    "version = 2\n\n[location]\nlatitude = 51.5\nlongitude = -0.12\n\n[profile.work]\ncity = Amman\ncountry = Jordan\n",
+			profile: "work",
+			want: map[string][2]string{
+				"city":      {"Amman", "profile"},
+				"latitude":  {"", "default"},
+				"longitude": {"", "default"},
+			},
+		},
+		{
+			name: "env city replaces user coordinates",
+			user: "version = 2\n\n[location]\ncity = Cairo\ncountry = Egypt\nlatitude = 30.0444\nlongitude = 31.2357\n",
+			env:  map[string]string{"ADHANCTL_CITY": "Mecca", "ADHANCTL_COUNTRY": "Saudi Arabia"},
+			want: map[string][2]string{
+				"city":     {"Mecca", "env"},
+				"country":  {"Saudi Arabia", "env"},
+				"latitude": {"", "default"},
+			},
+		},
+		{
+			name:  "flag city replaces env coordinates and country",
+			user:  "version = 2\n\n[location]\ncity = Cairo\ncountry = Egypt\n",
+			env:   map[string]string{"ADHANCTL_LATITUDE": "21.4225", "ADHANCTL_LONGITUDE": "39.8262"},
+			flags: []Setting{{"city", "Istanbul"}},
+			want: map[string][2]string{
+				"city":      {"Istanbul", "flag"},
+				"country":   {"", "default"},
+				"latitude":  {"", "default"},
+				"longitude": {"", "default"},
+			},
+		},
+		{
+			name:  "flag coordinates replace user city",
+			user:  "version = 2\n\n[location]\ncity = Cairo\ncountry = Egypt\n",
+			flags: []Setting{{"latitude", "21.4225"}, {"longitude", "39.8262"}},
+			want: map[string][2]string{
+				"city":      {"", "default"},
+				"latitude":  {"21.422500", "flag"},
+				"longitude": {"39.826200", "flag"},
+			},
+		},
+		{
+			name: "location keys in one layer combine",
+			user: "version = 2\n\n[location]\ncity = Cairo\ncountry = Egypt\nlatitude = 30.0444\nlongitude = 31.2357\n",
+			want: map[string][2]string{
+				"city":     {"Cairo", "user"},
+				"country":  {"Egypt", "user"},
+				"latitude": {"30.044400", "user"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			system, user := filepath.Join(dir, "system"), filepath.Join(dir, "user")
+			t.Setenv("XDG_CONFIG_DIRS", system)
+			t.Setenv("XDG_CONFIG_HOME", user)
+			t.Setenv("ADHANCTL_CONFIG", "")
+			SetPath("")
+			if tt.system != "" {
+				writeConfig(t, system, tt.system)
+			}
+			if tt.user != "" {
+				writeConfig(t, user, tt.user)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if tt.profile != "" {
+				if cfg, err = cfg.WithProfile(tt.profile); err != nil {
+					t.Fatalf("WithProfile: %v", err)
+				}
+			}
+			if cfg, err = cfg.WithFlags(tt.flags); err != nil {
+				t.Fatalf("WithFlags: %v", err)
+			}
+
+			for key, want := range tt.want {
+				got, err := cfg.Get(key)
+				if err != nil {
+					t.Fatalf("Get(%q): %v", key, err)
+				}
+				layer, _, _ := strings.Cut(cfg.Source(key), " ")
+				if got != want[0] || layer != want[1] {
+					t.Errorf("%s = %q from %s, want %q from %s", key, got, layer, want[0], want[1])
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidFlagKeepsLowerLayer(t *testing.T) {
+	cfg, err := Default().WithFlags([]Setting{{"method", "6"}, {"school", "1"}})
+	if err == nil {
+		t.Fatal("WithFlags accepted method 6")
+	}
+	if cfg.Method != 3 || cfg.School != 1 {
+		t.Errorf("got method %d school %d, want 3 and 1", cfg.Method, cfg.School)
+	}
+}
+
+func TestLoadLeavesOldFormatOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "system"))
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("ADHANCTL_CONFIG", "")
+	SetPath("")
+
+	old := "# my config\ncity = Cairo\ncountry = Egypt\nampm = true\n"
+	writeConfig(t, dir, old)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.City != "Cairo" || !cfg.AmPm {
+		t.Errorf("got city %q ampm %t, want Cairo and true", cfg.City, cfg.AmPm)
+	}
+
+	path := filepath.Join(dir, ConfigDirName, ConfigFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != old {
+		t.Errorf("Load rewrote the file:\n%s", data)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Errorf("Load left a backup: %v", err)
+	}
+}
+
+func TestLoadReportsOldFormatLines(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "system"))
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("ADHANCTL_CONFIG", "")
+	SetPath("")
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	// Migrating would move ampm, interval and method into sections and
+	// shift the lines below them.
+	writeConfig(t, dir, "# my config\ncity = Cairo\nampm = true\ninterval = 30m\nmethod = 5\n\nlatitude = abc\nfoo = 1\n")
+
+	_, err := Load()
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Load: got %v, want a ParseError", err)
+	}
+	if len(perr.Problems) != 1 || perr.Problems[0].Line != 7 {
+		t.Errorf("got problems %v, want one on line 7", perr.Problems)
+	}
+	if !strings.Contains(logs.String(), "line=8") {
+		t.Errorf("unknown key not reported on line 8:\n%s", logs.String())
+	}
+}
+
+func TestSaveWritesOnlyChanges(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "system"))
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("ADHANCTL_CONFIG", "")
+	SetPath("")
+
+	writeConfig(t, dir, "version = 2\n\n# keep me\n[display]\nshort = false\n")
+
+	cfg := Default()
+	cfg.City, cfg.Country, cfg.Method = "Cairo", "Egypt", 5
+	if err := cfg.Save(Default(), map[string]string{"ampm": "false"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ConfigDirName, ConfigFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	for _, want := range []string{"city = Cairo", "method = 5", "ampm = false", "# keep me"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("file lacks %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"school", "short", "cache_secs", "interval"} {
+		if strings.Contains(text, unwanted+" =") {
+			t.Errorf("file has default %q:\n%s", unwanted, text)
+		}
+	}
+}
+
+func TestUnset(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		section string
+		key     string
+		want    string
+	}{
+		{
+			name:    "comment goes with the key",
+			in:      "version = 2\n\n[display]\n# 12-hour clock\nampm = true\n# keep\nshort = true\n",
+			section: "display",
+			key:     "ampm",
+			want:    "version = 2\n\n[display]\n# keep\nshort = true\n",
+		},
+		{
+			name:    "section with only a commented key is dropped",
+			in:      "version = 2\n\n[display]\n# 12-hour clock\nampm = true\n\n[notify]\ninterval = 5m\n",
+			section: "display",
+			key:     "ampm",
+			want:    "version = 2\n\n[notify]\ninterval = 5m\n",
+		},
+		{
+			name:    "comment after a blank line stays",
+			in:      "version = 2\n\n[display]\n# about display\n\nampm = true\nshort = true\n",
+			section: "display",
+			key:     "ampm",
+			want:    "version = 2\n\n[display]\n# about display\n\nshort = true\n",
+		},
+		{
+			name:    "file header stays",
+			in:      "# my config\ncity = Cairo\n",
+			section: "",
+			key:     "city",
+			want:    "# my config\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.in), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !f.Unset(tt.section, tt.key) {
+				t.Fatalf("Unset(%q, %q) found nothing", tt.section, tt.key)
+			}
+			if got := string(f.Bytes()); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// eofInteractor answers every choice with io.EOF, as stdin does once the
+// piped answers run out.
+type eofInteractor struct {
+	choices int
+}
+
+func (e *eofInteractor) Printf(format string, args ...any) {}
+
+func (e *eofInteractor) Prompt(prompt string) (string, error) { return "", io.EOF }
+
+func (e *eofInteractor) PromptDefault(prompt, defaultValue string) (string, error) {
+	return "", io.EOF
+}
+
+func (e *eofInteractor) PromptChoice(prompt string, choices map[int]string, defaultChoice int) (int, error) {
+	e.choices++
+	if e.choices > 1 {
+		return defaultChoice, nil
+	}
+	return 0, io.EOF
+}
+
+func TestInitStopsAtEOF(t *testing.T) {
+	in := &eofInteractor{}
+	_, err := RunConfigInit(in, InitOptions{Answers: map[string]string{
+		"latitude":  "21.4225",
+		"longitude": "39.8262",
+	}})
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("got error %v, want io.EOF", err)
+	}
+	if in.choices != 1 {
+		t.Errorf("method asked %d times, want once", in.choices)
+	}
+}
+
+func TestParseUserSeesSystemProfiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", dir)
+	writeConfig(t, dir, "version = 2\n\n[profile.work]\nmethod = 23\n")
+
+	data := []byte("version = 2\nprofile = work\n")
+	cfg, problems, err := ParseUser(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("got problems %v, want none", problems)
+	}
+	if cfg.Profile != "work" {
+		t.Errorf("got profile %q, want work", cfg.Profile)
+	}
+
+	if _, problems := Parse(data); len(problems) == 0 {
+		t.Error("Parse found the system profile without the system config")
+	}
+}
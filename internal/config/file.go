@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is the config file kept line by line, so that edits made by
+// `config set` and `config unset` leave comments, blank lines and keys
+// this version doesn't know exactly as the user wrote them.
+type File struct {
+	Path  string
+	lines []string
+}
+
+// ReadFile loads the config file at path. A missing file reads as empty.
+func ReadFile(path string) (*File, error) {
+	f := &File{Path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	text := strings.TrimSuffix(string(data), "\n")
+	if text != "" {
+		f.lines = strings.Split(text, "\n")
+	}
+	return f, nil
+}
+
+// Get returns the value of key in section, where "" is the top level and
+// profiles are "profile.NAME".
+func (f *File) Get(section, key string) (string, bool) {
+	if i := f.find(section, key); i >= 0 {
+		_, value, _ := splitLine(f.lines[i])
+		return value, true
+	}
+	return "", false
+}
+
+// Set replaces key's line in section, or adds it after the section's last
+// setting, creating the section when needed.
+func (f *File) Set(section, key, value string) {
+	line := fmt.Sprintf("%s = %s", key, value)
+
+	if i := f.find(section, key); i >= 0 {
+		f.lines[i] = line
+		return
+	}
+
+	start, end, ok := f.bounds(section)
+	if !ok {
+		f.addSection(section, line)
+		return
+	}
+
+	// Insert after the last non-blank line, so the blank line separating
+	// this section from the next one stays put.
+	at := end
+	for at > start && strings.TrimSpace(f.lines[at-1]) == "" {
+		at--
+	}
+	f.lines = append(f.lines[:at], append([]string{line}, f.lines[at:]...)...)
+}
+
+// Unset removes key from section and reports whether it was there. The
+// comment lines directly above the key go with it, as they do when
+// groupIntoSections moves a key.
+func (f *File) Unset(section, key string) bool {
+	i := f.find(section, key)
+	if i < 0 {
+		return false
+	}
+	from := i
+	for from > 0 && strings.HasPrefix(strings.TrimSpace(f.lines[from-1]), "#") {
+		from--
+	}
+	if from == 0 {
+		// A block at the very top is the file's own header.
+		from = i
+	}
+	f.lines = append(f.lines[:from], f.lines[i+1:]...)
+
+	// Drop the header too if nothing is left under it.
+	if start, end, _ := f.bounds(section); isSection(section) && blank(f.lines[start:end]) {
+		for end < len(f.lines) && strings.TrimSpace(f.lines[end]) == "" {
+			end++
+		}
+		f.lines = append(f.lines[:start-1], f.lines[end:]...)
+		for len(f.lines) > 0 && strings.TrimSpace(f.lines[len(f.lines)-1]) == "" {
+			f.lines = f.lines[:len(f.lines)-1]
+		}
+	}
+	return true
+}
+
+// Profiles returns the names of the file's [profile.NAME] sections in the
+// order they appear.
+func (f *File) Profiles() []string {
+	var names []string
+	for _, line := range f.lines {
+		if name, ok := sectionName(line); ok {
+			if p, ok := strings.CutPrefix(name, "profile."); ok && p != "" {
+				names = append(names, p)
+			}
+		}
+	}
+	return names
+}
+
+// addSection adds a new section holding line. Known sections go in the
+// order Sections lists them, ahead of profiles and unknown sections.
+func (f *File) addSection(section, line string) {
+	at := len(f.lines)
+	for i, l := range f.lines {
+		if name, ok := sectionName(l); ok && sectionRank(name) > sectionRank(section) {
+			at = i
+			break
+		}
+	}
+
+	lines := []string{"[" + section + "]", line}
+	if at > 0 && strings.TrimSpace(f.lines[at-1]) != "" {
+		lines = append([]string{""}, lines...)
+	}
+	if at < len(f.lines) {
+		lines = append(lines, "")
+	}
+	f.lines = append(f.lines[:at], append(lines, f.lines[at:]...)...)
+}
+
+func blank(lines []string) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *File) Bytes() []byte {
+	if len(f.lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(f.lines, "\n") + "\n")
+}
+
+func (f *File) Write() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(f.Path, f.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+func (f *File) find(section, key string) int {
+	start, end, ok := f.bounds(section)
+	if !ok {
+		return -1
+	}
+	for i := start; i < end; i++ {
+		if k, _, ok := splitLine(f.lines[i]); ok && k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// bounds returns the range of lines belonging to section, excluding its
+// header. The top level always exists and runs up to the first header.
+func (f *File) bounds(section string) (int, int, bool) {
+	start := -1
+	if section == "" {
+		start = 0
+	}
+
+	for i, line := range f.lines {
+		name, ok := sectionName(line)
+		if !ok {
+			continue
+		}
+		if start >= 0 {
+			return start, i, true
+		}
+		if name == section {
+			start = i + 1
+		}
+	}
+
+	if start < 0 {
+		return 0, 0, false
+	}
+	return start, len(f.lines), true
+}
+
+func sectionName(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+		return strings.TrimSpace(line[1 : len(line)-1]), true
+	}
+	return "", false
+}
+
+func splitLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
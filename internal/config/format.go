@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CurrentVersion is the config format this version writes. Version 1 is
+// the original flat key = value file, which has no version line.
+//
+// Version 2 groups keys into INI-style sections, while [profile.NAME]
+// sections still hold flat overrides:
+//
+//	version = 2
+//	profile = work
+//
+//	[location]
+//	city = London
+//
+//	[profile.work]
+//	city = Amman
+const CurrentVersion = 2
+
+// Sections lists the version 2 sections in the order they are written,
+// with the keys each one holds.
+var Sections = []struct {
+	Name string
+	Keys []string
+}{
+	{"location", []string{"city", "country", "latitude", "longitude", "elevation", "method", "school", "source", "timetable"}},
+	{"display", []string{"ampm", "arabic", "short", "waybar_qibla"}},
+	{"service", []string{"cache_secs", "interval"}},
+	{"prayer", []string{"isha_end", "window_warn", "makruh_sunrise", "makruh_zawal", "makruh_sunset"}},
+	{"jumuah", []string{"jumuah_khutbah", "jumuah_iqamah", "jumuah_reminder", "kahf_reminder"}},
+	{"ramadan", []string{"ramadan", "suhoor_warnings", "taraweeh"}},
+	{"calendar", []string{"event_notify", "fasting_reminders"}},
+	{"travel", []string{"travel", "location_file", "gpsd", "musafir", "musafir_distance", "combine", "home_profile"}},
+	{"api", []string{"api_url", "api_timeout", "user_agent", "proxy"}},
+}
+
+// SectionFor returns the section a key belongs in, or "" for top-level
+// keys such as version and profile, and for unknown keys.
+func SectionFor(key string) string {
+	for _, s := range Sections {
+		for _, k := range s.Keys {
+			if k == key {
+				return s.Name
+			}
+		}
+	}
+	return ""
+}
+
+func isSection(name string) bool {
+	return sectionRank(name) < len(Sections)
+}
+
+// sectionRank orders sections as Sections lists them, with profiles and
+// unknown sections after all of those.
+func sectionRank(name string) int {
+	for i, s := range Sections {
+		if s.Name == name {
+			return i
+		}
+	}
+	return len(Sections)
+}
+
+// migrations[i] upgrades a file from version i+1 to i+2.
+var migrations = []func(*File){
+	groupIntoSections,
+}
+
+// OpenFile reads the config file at path, upgrading it to CurrentVersion
+// first if it is older. The original is kept as PATH.vN.bak. A missing
+// file opens as an empty one in the current format.
+func OpenFile(path string) (*File, error) {
+	f, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := f.Version()
+	if err != nil {
+		return nil, err
+	}
+	if len(f.lines) == 0 {
+		// A new file starts out in the current format.
+		f.lines = []string{fmt.Sprintf("version = %d", CurrentVersion)}
+		return f, nil
+	}
+	if from >= CurrentVersion {
+		return f, nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := os.WriteFile(backup, f.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("backing up config before migration: %w", err)
+	}
+
+	f.Migrate()
+	if err := f.Write(); err != nil {
+		return nil, err
+	}
+
+	slog.Info("migrated config", "path", path, "from", from, "to", CurrentVersion, "backup", backup)
+	return f, nil
+}
+
+// Version returns the file's format version. Files without a version line
+// are version 1.
+func (f *File) Version() (int, error) {
+	value, ok := f.Get("", "version")
+	if !ok {
+		return 1, nil
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid config version %q", value)
+	}
+	if v > CurrentVersion {
+		return 0, fmt.Errorf("config version %d is newer than this adhanctl supports (%d)", v, CurrentVersion)
+	}
+	return v, nil
+}
+
+// Migrate runs the migrations from the file's version up to
+// CurrentVersion in memory.
+func (f *File) Migrate() {
+	from, err := f.Version()
+	if err != nil {
+		return
+	}
+	for v := from; v < CurrentVersion; v++ {
+		migrations[v-1](f)
+	}
+}
+
+// groupIntoSections moves version 1's top-level keys into their version 2
+// sections. Comment lines directly above a key move with it; everything
+// else, including profile sections and unknown keys, stays where it was.
+func groupIntoSections(f *File) {
+	end := len(f.lines)
+	for i, line := range f.lines {
+		if _, ok := sectionName(line); ok {
+			end = i
+			break
+		}
+	}
+
+	var top []string
+	grouped := make(map[string][]string)
+	var pending []string
+
+	for _, line := range f.lines[:end] {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			pending = append(pending, line)
+			continue
+		case trimmed == "":
+			top = append(top, pending...)
+			top = append(top, line)
+			pending = nil
+			continue
+		}
+
+		key, _, _ := splitLine(line)
+		section := SectionFor(key)
+		if section == "" {
+			top = append(top, pending...)
+			top = append(top, line)
+		} else {
+			grouped[section] = append(grouped[section], pending...)
+			grouped[section] = append(grouped[section], line)
+		}
+		pending = nil
+	}
+	top = append(top, pending...)
+
+	// Keep the leading comment block first, then the version line.
+	head := 0
+	for head < len(top) && strings.HasPrefix(strings.TrimSpace(top[head]), "#") {
+		head++
+	}
+	var lines []string
+	lines = append(lines, top[:head]...)
+	if head > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "version = 2")
+	for _, line := range top[head:] {
+		// Skip the blank lines left behind by keys that moved.
+		if strings.TrimSpace(line) != "" || strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, line)
+		}
+	}
+	for strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, s := range Sections {
+		if len(grouped[s.Name]) == 0 {
+			continue
+		}
+		if strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+s.Name+"]")
+		lines = append(lines, grouped[s.Name]...)
+	}
+
+	rest := f.lines[end:]
+	if len(rest) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+		lines = append(lines, "")
+	}
+	f.lines = append(lines, rest...)
+}
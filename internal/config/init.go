@@ -0,0 +1,394 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+)
+
+// ErrInvalidChoice is returned by PromptChoice when the answer isn't one
+// of the choices, so the question can be asked again.
+var ErrInvalidChoice = errors.New("invalid choice")
+
+// Interactor is how config init talks to the user. All of its output goes
+// through Printf so another front end can take over both directions.
+type Interactor interface {
+	Printf(format string, args ...any)
+	Prompt(prompt string) (string, error)
+	PromptDefault(prompt, defaultValue string) (string, error)
+	PromptChoice(prompt string, choices map[int]string, defaultChoice int) (int, error)
+}
+
+type StdioInteractor struct {
+	Logger *slog.Logger
+
+	reader *bufio.Reader
+}
+
+func (s *StdioInteractor) Printf(format string, args ...any) {
+	fmt.Printf(format, args...)
+}
+
+func (s *StdioInteractor) readLine() (string, error) {
+	if s.reader == nil {
+		s.reader = bufio.NewReader(os.Stdin)
+	}
+	input, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
+}
+
+func (s *StdioInteractor) Prompt(prompt string) (string, error) {
+	fmt.Printf("%s: ", prompt)
+	return s.readLine()
+}
+
+func (s *StdioInteractor) PromptDefault(prompt, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", prompt, defaultValue)
+	} else {
+		fmt.Printf("%s: ", prompt)
+	}
+	input, err := s.readLine()
+	if err != nil {
+		return "", err
+	}
+	if input == "" {
+		return defaultValue, nil
+	}
+	return input, nil
+}
+
+func (s *StdioInteractor) PromptChoice(prompt string, choices map[int]string, defaultChoice int) (int, error) {
+	fmt.Printf("%s:\n", prompt)
+	keys := make([]int, 0, len(choices))
+	for k := range choices {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		fmt.Printf("  %2d: %s\n", k, choices[k])
+	}
+	fmt.Printf("Enter number [%d]: ", defaultChoice)
+	input, err := s.readLine()
+	if err != nil {
+		return 0, err
+	}
+	if input == "" {
+		return defaultChoice, nil
+	}
+	val, err := strconv.Atoi(input)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is not a number", ErrInvalidChoice, input)
+	}
+	if _, ok := choices[val]; !ok {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidChoice, val)
+	}
+	return val, nil
+}
+
+// InitOptions controls config init beyond the questions themselves.
+type InitOptions struct {
+	// Answers holds values for config keys, as written in the file, that
+	// are then not asked for, e.g. from command-line flags.
+	Answers map[string]string
+
+	// NonInteractive never prompts: anything not in Answers takes the
+	// suggested or default value.
+	NonInteractive bool
+
+	// Validate, if set, is called with the finished config before it is
+	// returned, e.g. to try fetching today's times for the location.
+	Validate func(*Config) error
+}
+
+type initRun struct {
+	in   Interactor
+	opts InitOptions
+	cfg  *Config
+}
+
+func RunConfigInit(interactor Interactor, opts InitOptions) (*Config, error) {
+	r := &initRun{in: interactor, opts: opts, cfg: Default()}
+
+	if !opts.NonInteractive {
+		interactor.Printf("\nadhanctl - First Run Setup\n")
+		interactor.Printf("==========================\n")
+	}
+
+	if err := r.location(); err != nil {
+		return nil, err
+	}
+
+	if !opts.NonInteractive {
+		interactor.Printf("\n")
+	}
+	method := SuggestMethod(r.cfg.Country)
+	if err := r.choice("method", "Calculation Method", CalculationMethods, method); err != nil {
+		return nil, err
+	}
+
+	if !opts.NonInteractive {
+		interactor.Printf("\n")
+	}
+	if err := r.choice("school", "Asr Calculation School", Schools, SuggestSchool(r.cfg.Country)); err != nil {
+		return nil, err
+	}
+
+	for _, q := range []struct{ key, prompt, def string }{
+		{"ampm", "Use 12-hour format (AM/PM)", "false"},
+		{"arabic", "Display Hijri month/weekday in Arabic", "false"},
+		{"short", "Short Waybar text without the countdown", "false"},
+		{"cache_secs", "Cache prayer times for how many seconds", strconv.Itoa(r.cfg.CacheSecs)},
+	} {
+		if err := r.ask(q.key, q.prompt, q.def); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Validate != nil {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.cfg, nil
+}
+
+func (r *initRun) location() error {
+	_, hasLat := r.opts.Answers["latitude"]
+	_, hasLon := r.opts.Answers["longitude"]
+	_, hasCity := r.opts.Answers["city"]
+
+	if hasLat || hasLon {
+		if err := r.coordinates(); err != nil {
+			return err
+		}
+		if !hasCity {
+			return nil
+		}
+	}
+
+	if r.opts.NonInteractive && !hasCity && !hasLat {
+		return fmt.Errorf("a city or coordinates are required")
+	}
+
+	city, err := r.answer("city", "City (leave empty to enter coordinates)", "")
+	if err != nil {
+		return err
+	}
+
+	if city == "" {
+		return r.coordinates()
+	}
+
+	known, found := geo.LookupCity(city, r.opts.Answers["country"])
+	if !found && !r.opts.NonInteractive {
+		if suggestions := geo.SuggestCities(city, 1); len(suggestions) > 0 {
+			answer, err := r.in.PromptDefault(fmt.Sprintf("Did you mean %s?", suggestions[0]), "y")
+			if err != nil {
+				return err
+			}
+			if yes(answer) {
+				known, found = suggestions[0], true
+				city = known.Name
+			}
+		}
+	}
+	r.cfg.City = city
+
+	defaultCountry := ""
+	if found {
+		defaultCountry = known.Country
+	}
+	if err := r.ask("country", "Country", defaultCountry); err != nil {
+		return err
+	}
+	if r.cfg.Country == "" {
+		return fmt.Errorf("unknown city %q: a country is required", city)
+	}
+
+	if found && !hasLat {
+		answer, err := r.answer("", fmt.Sprintf("Save coordinates %.4f, %.4f for offline use?",
+			known.Latitude, known.Longitude), "y")
+		if err != nil {
+			return err
+		}
+		if yes(answer) {
+			r.cfg.Latitude, r.cfg.Longitude = known.Latitude, known.Longitude
+		}
+	}
+	return nil
+}
+
+func (r *initRun) coordinates() error {
+	if err := r.ask("latitude", "Latitude", ""); err != nil {
+		return err
+	}
+	if err := r.ask("longitude", "Longitude", ""); err != nil {
+		return err
+	}
+	if !r.cfg.HasCoordinates() {
+		return fmt.Errorf("both latitude and longitude are required")
+	}
+	return nil
+}
+
+// answer returns the preset answer for key, or asks for it.
+func (r *initRun) answer(key, prompt, def string) (string, error) {
+	if v, ok := r.opts.Answers[key]; ok && key != "" {
+		return v, nil
+	}
+	if r.opts.NonInteractive {
+		return def, nil
+	}
+	return r.in.PromptDefault(prompt, def)
+}
+
+// ask sets key from its answer, asking again while the value is invalid.
+func (r *initRun) ask(key, prompt, def string) error {
+	for {
+		value, err := r.answer(key, prompt, def)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return nil
+		}
+
+		err = r.cfg.set(key, yesNo(value))
+		if err == nil {
+			return nil
+		}
+		if _, preset := r.opts.Answers[key]; preset || r.opts.NonInteractive {
+			return err
+		}
+		r.in.Printf("  %v\n", err)
+	}
+}
+
+func (r *initRun) choice(key, prompt string, choices map[int]string, def int) error {
+	if v, ok := r.opts.Answers[key]; ok || r.opts.NonInteractive {
+		if !ok {
+			v = strconv.Itoa(def)
+		}
+		return r.cfg.set(key, v)
+	}
+
+	for {
+		n, err := r.in.PromptChoice(prompt, choices, def)
+		if err == nil {
+			return r.cfg.set(key, strconv.Itoa(n))
+		}
+		if !errors.Is(err, ErrInvalidChoice) {
+			return err
+		}
+		r.in.Printf("  %v\n", err)
+	}
+}
+
+func (r *initRun) validate() error {
+	if !r.opts.NonInteractive {
+		r.in.Printf("\nChecking the location...\n")
+	}
+
+	err := r.opts.Validate(r.cfg)
+	if err == nil {
+		return nil
+	}
+	if r.opts.NonInteractive {
+		return fmt.Errorf("checking location: %w", err)
+	}
+
+	r.in.Printf("  %v\n", err)
+	answer, perr := r.in.PromptDefault("Save anyway?", "n")
+	if perr != nil {
+		return perr
+	}
+	if !yes(answer) {
+		return fmt.Errorf("checking location: %w", err)
+	}
+	return nil
+}
+
+// SuggestMethod returns the calculation method usually followed in a
+// country, or Muslim World League where there is no clear local choice.
+func SuggestMethod(country string) int {
+	if m, ok := countryMethods[geo.CanonicalCountry(country)]; ok {
+		return m
+	}
+	return 3
+}
+
+// SuggestSchool returns Hanafi for countries where it predominates.
+func SuggestSchool(country string) int {
+	switch geo.CanonicalCountry(country) {
+	case "pakistan", "india", "bangladesh", "afghanistan", "turkey":
+		return 1
+	}
+	return 0
+}
+
+var countryMethods = map[string]int{
+	"pakistan":             1,
+	"india":                1,
+	"bangladesh":           1,
+	"afghanistan":          1,
+	"united states":        2,
+	"canada":               2,
+	"saudi arabia":         4,
+	"yemen":                4,
+	"egypt":                5,
+	"sudan":                5,
+	"syria":                5,
+	"lebanon":              5,
+	"iraq":                 5,
+	"iran":                 7,
+	"bahrain":              8,
+	"oman":                 8,
+	"kuwait":               9,
+	"qatar":                10,
+	"singapore":            11,
+	"france":               12,
+	"turkey":               13,
+	"russia":               14,
+	"united kingdom":       15,
+	"united arab emirates": 16,
+	"malaysia":             17,
+	"brunei":               17,
+	"tunisia":              18,
+	"algeria":              19,
+	"indonesia":            20,
+	"morocco":              21,
+	"portugal":             22,
+	"jordan":               23,
+	"palestine":            23,
+}
+
+func yes(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "true":
+		return true
+	}
+	return false
+}
+
+// yesNo maps y/yes/n/no answers to the true/false the file uses.
+func yesNo(answer string) string {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return "true"
+	case "n", "no":
+		return "false"
+	}
+	return answer
+}
@@ -0,0 +1,242 @@
+package geo
+
+import (
+	_ "embed"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MinPopulation is the smallest urban area kept in the embedded gazetteer.
+// National capitals are kept whatever their size.
+const MinPopulation = 1000000
+
+//go:embed cities.tsv
+var citiesTSV string
+
+type City struct {
+	Name       string
+	Country    string
+	Latitude   float64
+	Longitude  float64
+	Timezone   string
+	Population int
+	Capital    bool
+}
+
+func (c City) String() string {
+	return c.Name + ", " + c.Country
+}
+
+var countryAliases = map[string]string{
+	"uk":            "united kingdom",
+	"gb":            "united kingdom",
+	"great britain": "united kingdom",
+	"england":       "united kingdom",
+	"scotland":      "united kingdom",
+	"wales":         "united kingdom",
+	"us":            "united states",
+	"usa":           "united states",
+	"america":       "united states",
+	"uae":           "united arab emirates",
+	"ksa":           "saudi arabia",
+	"saudi":         "saudi arabia",
+	"turkiye":       "turkey",
+	"cote divoire":  "ivory coast",
+	"drc":           "democratic republic of the congo",
+	"czechia":       "czech republic",
+	"holland":       "netherlands",
+}
+
+var loadCities = sync.OnceValue(func() []City {
+	var cities []City
+	for _, line := range strings.Split(citiesTSV, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		if len(f) != 7 {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(f[2], 64)
+		lon, err2 := strconv.ParseFloat(f[3], 64)
+		pop, err3 := strconv.Atoi(f[5])
+		capital, err4 := strconv.ParseBool(f[6])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+		if pop < MinPopulation && !capital {
+			continue
+		}
+		cities = append(cities, City{
+			Name:       f[0],
+			Country:    f[1],
+			Latitude:   lat,
+			Longitude:  lon,
+			Timezone:   f[4],
+			Population: pop,
+			Capital:    capital,
+		})
+	}
+	return cities
+})
+
+func Cities() []City {
+	return loadCities()
+}
+
+// LookupCity finds a city by name, ignoring case, accents and punctuation.
+// An empty country matches the most populous city of that name.
+func LookupCity(name, country string) (City, bool) {
+	n := normalize(name)
+	c := normalizeCountry(country)
+
+	var best City
+	found := false
+	for _, city := range Cities() {
+		if normalize(city.Name) != n {
+			continue
+		}
+		if c != "" && normalize(city.Country) != c {
+			continue
+		}
+		if !found || city.Population > best.Population {
+			best = city
+			found = true
+		}
+	}
+	return best, found
+}
+
+// NearestCity returns the gazetteer city closest to the coordinates and
+// its distance in kilometres.
+func NearestCity(lat, lon float64) (City, float64) {
+	var best City
+	bestDist := math.MaxFloat64
+	for _, c := range Cities() {
+		if d := Distance(lat, lon, c.Latitude, c.Longitude); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, bestDist
+}
+
+// LargestCityIn returns the most populous city in an IANA timezone, the
+// best guess for where someone is when only their clock changed.
+func LargestCityIn(timezone string) (City, bool) {
+	var best City
+	found := false
+	for _, c := range Cities() {
+		if c.Timezone != timezone {
+			continue
+		}
+		if !found || c.Population > best.Population {
+			best = c
+			found = true
+		}
+	}
+	return best, found
+}
+
+// SuggestCities returns up to limit cities whose names are close to name,
+// for "did you mean" hints. Larger cities win ties.
+func SuggestCities(name string, limit int) []City {
+	n := normalize(name)
+	if n == "" {
+		return nil
+	}
+
+	maxDist := 1 + len(n)/4
+
+	type candidate struct {
+		city City
+		dist int
+	}
+	var candidates []candidate
+	for _, city := range Cities() {
+		cn := normalize(city.Name)
+		d := levenshtein(n, cn)
+		if strings.HasPrefix(cn, n) && len(n) >= 3 {
+			d = min(d, 1)
+		}
+		if d <= maxDist {
+			candidates = append(candidates, candidate{city, d})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].city.Population > candidates[j].city.Population
+	})
+
+	result := make([]City, 0, limit)
+	for _, c := range candidates {
+		if len(result) == limit {
+			break
+		}
+		result = append(result, c.city)
+	}
+	return result
+}
+
+// CanonicalCountry folds case, accents and common aliases ("UK", "KSA")
+// so country names can be compared.
+func CanonicalCountry(s string) string {
+	return normalizeCountry(s)
+}
+
+func normalizeCountry(s string) string {
+	n := normalize(s)
+	if alias, ok := countryAliases[n]; ok {
+		return normalize(alias)
+	}
+	return n
+}
+
+var foldAccents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a", "ā", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "ē", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ı", "i", "ī", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o", "ō", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ū", "u",
+	"ç", "c", "ş", "s", "ğ", "g", "ñ", "n", "ž", "z", "š", "s", "č", "c", "ć", "c",
+)
+
+func normalize(s string) string {
+	s = foldAccents.Replace(strings.ToLower(strings.TrimSpace(s)))
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		} else if r == ' ' || r == '-' {
+			sb.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
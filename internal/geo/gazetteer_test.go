@@ -0,0 +1,55 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupCity(t *testing.T) {
+	tests := []struct {
+		name, country string
+		want          string
+		wantOK        bool
+	}{
+		{"Surat", "", "Surat, India", true},
+		{"São Paulo", "", "Sao Paulo, Brazil", true},
+		{"san jose", "", "San Jose, United States", true},
+		{"San Jose", "Costa Rica", "San Jose, Costa Rica", true},
+		{"Valencia", "Venezuela", "Valencia, Venezuela", true},
+		{"Male", "Maldives", "Male, Maldives", true},
+		{"Mecca", "KSA", "Mecca, Saudi Arabia", true},
+		{"Atlantis", "", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := LookupCity(tt.name, tt.country)
+		if ok != tt.wantOK || (ok && got.String() != tt.want) {
+			t.Errorf("LookupCity(%q, %q) = %s, %t; want %s, %t", tt.name, tt.country, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestCitiesMeetThreshold checks every row of cities.tsv loads, so none is
+// a small city dropped by the filter, and that capitals are marked.
+func TestCitiesMeetThreshold(t *testing.T) {
+	rows := 0
+	for _, line := range strings.Split(citiesTSV, "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			rows++
+		}
+	}
+	if n := len(Cities()); n != rows {
+		t.Errorf("loaded %d of %d cities", n, rows)
+	}
+
+	capitals := make(map[string]bool)
+	for _, c := range Cities() {
+		if c.Capital {
+			capitals[c.Country] = true
+		}
+	}
+	for _, country := range []string{"Maldives", "Brunei", "Comoros", "Fiji", "Iceland", "Suriname", "Sri Lanka", "Tanzania"} {
+		if !capitals[country] {
+			t.Errorf("no capital for %s", country)
+		}
+	}
+}
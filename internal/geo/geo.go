@@ -0,0 +1,108 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+const EarthRadiusKm = 6371.0
+
+const (
+	KaabaLatitude  = 21.422487
+	KaabaLongitude = 39.826206
+)
+
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := radians(lat1), radians(lat2)
+	dφ := radians(lat2 - lat1)
+	dλ := radians(lon2 - lon1)
+
+	a := math.Sin(dφ/2)*math.Sin(dφ/2) + math.Cos(φ1)*math.Cos(φ2)*math.Sin(dλ/2)*math.Sin(dλ/2)
+	return 2 * EarthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Bearing returns the initial great-circle bearing from the first point to
+// the second, in degrees clockwise from true north.
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := radians(lat1), radians(lat2)
+	dλ := radians(lon2 - lon1)
+
+	y := math.Sin(dλ) * math.Cos(φ2)
+	x := math.Cos(φ1)*math.Sin(φ2) - math.Sin(φ1)*math.Cos(φ2)*math.Cos(dλ)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+type Qibla struct {
+	Bearing  float64
+	Distance float64
+}
+
+func QiblaFrom(lat, lon float64) Qibla {
+	return Qibla{
+		Bearing:  Bearing(lat, lon, KaabaLatitude, KaabaLongitude),
+		Distance: Distance(lat, lon, KaabaLatitude, KaabaLongitude),
+	}
+}
+
+var compassPoints = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+func CompassPoint(bearing float64) string {
+	i := int(math.Round(math.Mod(bearing+360, 360)/22.5)) % len(compassPoints)
+	return compassPoints[i]
+}
+
+// Rose draws an ASCII compass with a needle pointing along bearing. Columns
+// are stretched to make up for terminal cells being taller than wide.
+func Rose(bearing float64) string {
+	const (
+		radius = 4
+		rows   = 2*radius + 1
+		cols   = 4*radius + 1
+		cy     = radius
+		cx     = 2 * radius
+	)
+
+	grid := make([][]rune, rows)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", cols))
+	}
+
+	grid[0][cx] = 'N'
+	grid[rows-1][cx] = 'S'
+	grid[cy][0] = 'W'
+	grid[cy][cols-1] = 'E'
+	for i := 1; i < radius; i++ {
+		grid[cy-i][cx] = '·'
+		grid[cy+i][cx] = '·'
+		grid[cy][cx-2*i] = '·'
+		grid[cy][cx+2*i] = '·'
+	}
+
+	θ := radians(bearing)
+	for r := 1; r <= radius-1; r++ {
+		y := cy - int(math.Round(float64(r)*math.Cos(θ)))
+		x := cx + int(math.Round(float64(2*r)*math.Sin(θ)))
+		grid[y][x] = '*'
+	}
+	y := cy - int(math.Round(float64(radius-1)*math.Cos(θ)))
+	x := cx + int(math.Round(float64(2*(radius-1))*math.Sin(θ)))
+	grid[y][x] = 'K'
+	grid[cy][cx] = '+'
+
+	lines := make([]string, rows)
+	for i, row := range grid {
+		lines[i] = strings.TrimRight(string(row), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,113 @@
+package geo
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxZoneDistanceKm is how far from the nearest known city its timezone is
+// still trusted. Past it, e.g. at sea, the nautical zone is used instead.
+const maxZoneDistanceKm = 800
+
+// zones.txt holds simplified timezone boundaries, one polygon per entry:
+// a line naming the zone, then indented "lat,lon" vertices.
+//
+//go:embed zones.txt
+var zonesTXT string
+
+// zone is one polygon of a timezone's boundary, with its bounding box.
+type zone struct {
+	name                           string
+	points                         [][2]float64
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// contains reports whether the point is inside the polygon, by counting
+// the edges a ray east from the point crosses.
+func (z *zone) contains(lat, lon float64) bool {
+	if lat < z.minLat || lat > z.maxLat || lon < z.minLon || lon > z.maxLon {
+		return false
+	}
+	inside := false
+	for i, j := 0, len(z.points)-1; i < len(z.points); j, i = i, i+1 {
+		lati, loni := z.points[i][0], z.points[i][1]
+		latj, lonj := z.points[j][0], z.points[j][1]
+		if (lati > lat) != (latj > lat) && lon < (lonj-loni)*(lat-lati)/(latj-lati)+loni {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+var loadZones = sync.OnceValue(func() []*zone {
+	var zones []*zone
+	var z *zone
+	for _, line := range strings.Split(zonesTXT, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			z = &zone{name: strings.TrimSpace(line), minLat: 90, maxLat: -90, minLon: 180, maxLon: -180}
+			zones = append(zones, z)
+			continue
+		}
+		if z == nil {
+			continue
+		}
+		for _, pair := range strings.Fields(line) {
+			latStr, lonStr, _ := strings.Cut(pair, ",")
+			lat, err1 := strconv.ParseFloat(latStr, 64)
+			lon, err2 := strconv.ParseFloat(lonStr, 64)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			z.points = append(z.points, [2]float64{lat, lon})
+			z.minLat, z.maxLat = min(z.minLat, lat), max(z.maxLat, lat)
+			z.minLon, z.maxLon = min(z.minLon, lon), max(z.maxLon, lon)
+		}
+	}
+	return zones
+})
+
+// TimezoneAt returns the IANA timezone for the coordinates. The point is
+// looked up in the embedded zone boundaries, where the first polygon that
+// contains it wins. Off the boundaries, e.g. on small islands or at sea
+// near a coast, it takes the zone of the nearest gazetteer city, and far
+// out at sea the nautical zone. It never depends on the host's clock.
+func TimezoneAt(lat, lon float64) string {
+	for _, z := range loadZones() {
+		if z.contains(lat, lon) {
+			return z.name
+		}
+	}
+	if city, dist := NearestCity(lat, lon); city.Timezone != "" && dist <= maxZoneDistanceKm {
+		return city.Timezone
+	}
+	return nauticalZone(lon)
+}
+
+func LocationAt(lat, lon float64) *time.Location {
+	loc, err := time.LoadLocation(TimezoneAt(lat, lon))
+	if err != nil {
+		return time.FixedZone(nauticalZone(lon), int(math.Round(lon/15))*3600)
+	}
+	return loc
+}
+
+// nauticalZone returns the Etc/GMT zone for lon. Etc zone signs are
+// inverted, so UTC+3 is "Etc/GMT-3".
+func nauticalZone(lon float64) string {
+	offset := int(math.Round(lon / 15))
+	switch {
+	case offset == 0:
+		return "Etc/GMT"
+	case offset > 0:
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	}
+	return fmt.Sprintf("Etc/GMT+%d", -offset)
+}
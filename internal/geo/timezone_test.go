@@ -0,0 +1,144 @@
+package geo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimezoneAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     string
+	}{
+		{"Indianapolis", 39.77, -86.16, "America/Indiana/Indianapolis"},
+		{"Gary", 41.59, -87.35, "America/Chicago"},
+		{"Evansville", 37.97, -87.57, "America/Chicago"},
+		{"Louisville", 38.25, -85.76, "America/New_York"},
+		{"Nashville", 36.16, -86.78, "America/Chicago"},
+		{"Knoxville", 35.96, -83.92, "America/New_York"},
+		{"El Paso", 31.76, -106.49, "America/Denver"},
+		{"Ciudad Juárez", 31.69, -106.42, "America/Ciudad_Juarez"},
+		{"Amarillo", 35.22, -101.83, "America/Chicago"},
+		{"Tucson", 32.22, -110.97, "America/Phoenix"},
+		{"Las Vegas", 36.17, -115.14, "America/Los_Angeles"},
+		{"Boise", 43.62, -116.2, "America/Boise"},
+		{"Rapid City", 44.08, -103.23, "America/Denver"},
+		{"Pierre", 44.37, -100.35, "America/Chicago"},
+		{"Regina", 50.45, -104.61, "America/Regina"},
+		{"Tijuana", 32.51, -117.04, "America/Tijuana"},
+		{"Hermosillo", 29.07, -110.96, "America/Hermosillo"},
+		{"Cancún", 21.16, -86.85, "America/Cancun"},
+		{"Kaliningrad", 54.71, 20.51, "Europe/Kaliningrad"},
+		{"Samara", 53.2, 50.15, "Europe/Samara"},
+		{"Yekaterinburg", 56.84, 60.6, "Asia/Yekaterinburg"},
+		{"Vladivostok", 43.12, 131.89, "Asia/Vladivostok"},
+		{"Hovd", 48.0, 91.64, "Asia/Hovd"},
+		{"Broken Hill", -31.95, 141.47, "Australia/Broken_Hill"},
+		{"Perth", -31.95, 115.86, "Australia/Perth"},
+		{"Makassar", -5.14, 119.42, "Asia/Makassar"},
+		{"Jayapura", -2.53, 140.72, "Asia/Jayapura"},
+		{"Lubumbashi", -11.66, 27.48, "Africa/Lubumbashi"},
+		{"Manaus", -3.12, -60.02, "America/Manaus"},
+		{"Rio Branco", -9.97, -67.81, "America/Rio_Branco"},
+		{"Ceuta", 35.89, -5.32, "Africa/Ceuta"},
+		{"Las Palmas", 28.12, -15.43, "Atlantic/Canary"},
+		{"Punta Arenas", -53.16, -70.91, "America/Punta_Arenas"},
+		{"Gaza", 31.5, 34.47, "Asia/Gaza"},
+		{"Hebron", 31.53, 35.1, "Asia/Hebron"},
+		{"Tel Aviv", 32.08, 34.78, "Asia/Jerusalem"},
+		{"Lahore", 31.55, 74.34, "Asia/Karachi"},
+		{"Amritsar", 31.63, 74.87, "Asia/Kolkata"},
+		{"Herat", 34.35, 62.2, "Asia/Kabul"},
+		{"Agartala", 23.83, 91.28, "Asia/Kolkata"},
+		{"Comilla", 23.46, 91.18, "Asia/Dhaka"},
+		{"Johor Bahru", 1.47, 103.76, "Asia/Kuala_Lumpur"},
+		{"Kuching", 1.55, 110.34, "Asia/Kuching"},
+		{"Pontianak", -0.03, 109.33, "Asia/Pontianak"},
+		{"Denpasar", -8.65, 115.22, "Asia/Makassar"},
+		{"Brazzaville", -4.27, 15.28, "Africa/Brazzaville"},
+		{"Kisangani", 0.52, 25.2, "Africa/Lubumbashi"},
+		{"Tlemcen", 34.88, -1.32, "Africa/Algiers"},
+		{"Laayoune", 27.15, -13.2, "Africa/El_Aaiun"},
+		{"Banyuwangi", -8.22, 114.37, "Asia/Jakarta"},
+		{"Mashhad", 36.3, 59.6, "Asia/Tehran"},
+		{"Osh", 40.53, 72.8, "Asia/Bishkek"},
+		{"Andijan", 40.78, 72.34, "Asia/Tashkent"},
+		{"Khujand", 40.28, 69.62, "Asia/Dushanbe"},
+		{"Almaty", 43.24, 76.95, "Asia/Almaty"},
+		{"Kashgar", 39.47, 75.99, "Asia/Urumqi"},
+		{"Lanzhou", 36.06, 103.83, "Asia/Shanghai"},
+		{"Shenzhen", 22.54, 114.06, "Asia/Shanghai"},
+		{"Hong Kong", 22.32, 114.17, "Asia/Hong_Kong"},
+		{"Heihe", 50.24, 127.49, "Asia/Shanghai"},
+		{"Blagoveshchensk", 50.27, 127.54, "Asia/Yakutsk"},
+		{"Khabarovsk", 48.48, 135.07, "Asia/Vladivostok"},
+		{"Ulan-Ude", 51.83, 107.6, "Asia/Irkutsk"},
+		{"Omsk", 54.99, 73.37, "Asia/Omsk"},
+		{"Ufa", 54.74, 55.97, "Asia/Yekaterinburg"},
+		{"Kazan", 55.8, 49.11, "Europe/Moscow"},
+		{"Volgograd", 48.71, 44.51, "Europe/Moscow"},
+	}
+	for _, tt := range tests {
+		if got := TimezoneAt(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("%s (%.2f, %.2f) = %s, want %s", tt.name, tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+// TestTimezoneAtCities checks every gazetteer city lies inside a boundary
+// whose zone keeps the same time as the city's own zone.
+func TestTimezoneAtCities(t *testing.T) {
+	var instants []time.Time
+	for month := time.January; month <= time.December; month++ {
+		instants = append(instants, time.Date(2026, month, 15, 12, 0, 0, 0, time.UTC))
+	}
+	for _, c := range Cities() {
+		var got string
+		for _, z := range loadZones() {
+			if z.contains(c.Latitude, c.Longitude) {
+				got = z.name
+				break
+			}
+		}
+		if got == "" {
+			t.Errorf("%s (%.2f, %.2f) is outside every boundary", c, c.Latitude, c.Longitude)
+			continue
+		}
+		if !sameTime(t, got, c.Timezone, instants) {
+			t.Errorf("%s (%.2f, %.2f) = %s, want %s", c, c.Latitude, c.Longitude, got, c.Timezone)
+		}
+	}
+}
+
+// TestZonesLoad checks every zone named in the boundaries exists.
+func TestZonesLoad(t *testing.T) {
+	for _, z := range loadZones() {
+		if len(z.points) < 3 {
+			t.Errorf("%s has %d points", z.name, len(z.points))
+		}
+		if _, err := time.LoadLocation(z.name); err != nil {
+			t.Errorf("%s: %v", z.name, err)
+		}
+	}
+}
+
+func sameTime(t *testing.T, a, b string, instants []time.Time) bool {
+	t.Helper()
+	la, err := time.LoadLocation(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb, err := time.LoadLocation(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, when := range instants {
+		_, oa := when.In(la).Zone()
+		_, ob := when.In(lb).Zone()
+		if oa != ob {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package hijri
+
+import (
+	"fmt"
+	"time"
+)
+
+type Kind int
+
+const (
+	Holiday Kind = iota
+	Fast
+)
+
+type Occasion struct {
+	Month int
+	Day   int
+	Name  string
+	Kind  Kind
+	// Night marks occasions observed on the night before the date, which in
+	// the Islamic day begins at the preceding Maghrib.
+	Night bool
+}
+
+var Occasions = []Occasion{
+	{Month: Muharram, Day: 1, Name: "Islamic New Year"},
+	{Month: Muharram, Day: 9, Name: "Tasu'a", Kind: Fast},
+	{Month: Muharram, Day: 10, Name: "Ashura", Kind: Fast},
+	{Month: Ramadan, Day: 1, Name: "Start of Ramadan"},
+	{Month: Ramadan, Day: 21, Name: "Laylat al-Qadr (21st night)", Night: true},
+	{Month: Ramadan, Day: 23, Name: "Laylat al-Qadr (23rd night)", Night: true},
+	{Month: Ramadan, Day: 25, Name: "Laylat al-Qadr (25th night)", Night: true},
+	{Month: Ramadan, Day: 27, Name: "Laylat al-Qadr (27th night)", Night: true},
+	{Month: Ramadan, Day: 29, Name: "Laylat al-Qadr (29th night)", Night: true},
+	{Month: Shawwal, Day: 1, Name: "Eid al-Fitr"},
+	{Month: DhuAlHijjah, Day: 9, Name: "Day of Arafah", Kind: Fast},
+	{Month: DhuAlHijjah, Day: 10, Name: "Eid al-Adha"},
+}
+
+type Observance struct {
+	Date  time.Time
+	Hijri Date
+	Occasion
+}
+
+func (o Observance) String() string {
+	return fmt.Sprintf("%s (%s)", o.Name, o.Hijri)
+}
+
+// Upcoming lists observances on each of the given number of days from
+// start. offset shifts the arithmetical calendar by whole days to agree
+// with a sighting-based date, see Offset. Named fast days like Ashura and
+// Arafah are always listed; with fasting set it also includes the
+// Monday/Thursday and White Days (13-15) voluntary fasts.
+func Upcoming(start time.Time, days, offset int, fasting bool) []Observance {
+	var result []Observance
+
+	for i := range days {
+		day := start.AddDate(0, 0, i)
+		h := FromTime(day.AddDate(0, 0, offset))
+
+		for _, o := range On(h, day.Weekday(), fasting) {
+			result = append(result, Observance{Date: day, Hijri: h, Occasion: o})
+		}
+	}
+
+	return result
+}
+
+func On(h Date, weekday time.Weekday, fasting bool) []Occasion {
+	var result []Occasion
+
+	for _, o := range Occasions {
+		if o.Month == h.Month && o.Day == h.Day {
+			result = append(result, o)
+		}
+	}
+
+	if !fasting || !canFast(h) {
+		return result
+	}
+
+	if h.Day >= 13 && h.Day <= 15 {
+		result = append(result, Occasion{Month: h.Month, Day: h.Day, Name: "White Day fast", Kind: Fast})
+	}
+	if weekday == time.Monday || weekday == time.Thursday {
+		result = append(result, Occasion{Month: h.Month, Day: h.Day, Name: weekday.String() + " fast", Kind: Fast})
+	}
+
+	return result
+}
+
+// canFast excludes Ramadan, whose fast is obligatory anyway, and the days
+// of Eid and Tashriq on which fasting is forbidden.
+func canFast(h Date) bool {
+	switch {
+	case h.Month == Ramadan:
+		return false
+	case h.Month == Shawwal && h.Day == 1:
+		return false
+	case h.Month == DhuAlHijjah && h.Day >= 10 && h.Day <= 13:
+		return false
+	}
+	return true
+}
+
+// Offset returns how many days the arithmetical calendar must be shifted
+// on t's date to match actual, e.g. the Hijri date reported by the API.
+func Offset(actual Date, t time.Time) int {
+	tabular := FromTime(t)
+	return int(toJD(actual.Year, actual.Month, actual.Day) - toJD(tabular.Year, tabular.Month, tabular.Day))
+}
@@ -0,0 +1,149 @@
+package hijri
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/api"
+)
+
+const (
+	Muharram = iota + 1
+	Safar
+	RabiAlAwwal
+	RabiAlThani
+	JumadaAlUla
+	JumadaAlAkhirah
+	Rajab
+	Shaban
+	Ramadan
+	Shawwal
+	DhuAlQadah
+	DhuAlHijjah
+)
+
+var MonthNames = map[int]string{
+	Muharram:        "Muharram",
+	Safar:           "Safar",
+	RabiAlAwwal:     "Rabi' al-Awwal",
+	RabiAlThani:     "Rabi' al-Thani",
+	JumadaAlUla:     "Jumada al-Ula",
+	JumadaAlAkhirah: "Jumada al-Akhirah",
+	Rajab:           "Rajab",
+	Shaban:          "Sha'ban",
+	Ramadan:         "Ramadan",
+	Shawwal:         "Shawwal",
+	DhuAlQadah:      "Dhu al-Qi'dah",
+	DhuAlHijjah:     "Dhu al-Hijjah",
+}
+
+var arabicMonthNames = map[int]string{
+	Muharram:        "مُحَرَّم",
+	Safar:           "صَفَر",
+	RabiAlAwwal:     "رَبيع الأوَّل",
+	RabiAlThani:     "رَبيع الثاني",
+	JumadaAlUla:     "جُمادى الأولى",
+	JumadaAlAkhirah: "جُمادى الآخرة",
+	Rajab:           "رَجَب",
+	Shaban:          "شَعْبان",
+	Ramadan:         "رَمَضان",
+	Shawwal:         "شَوّال",
+	DhuAlQadah:      "ذوالقعدة",
+	DhuAlHijjah:     "ذوالحجة",
+}
+
+// Weekday names as the AlAdhan API gives them.
+var (
+	weekdayNames = [7]string{"Al Ahad", "Al Athnayn", "Al Thalaata", "Al Arba'a", "Al Khamees", "Al Juma'a", "Al Sabt"}
+
+	arabicWeekdayNames = [7]string{"الاحد", "الاثنين", "الثلاثاء", "الاربعاء", "الخميس", "الجمعة", "السبت"}
+)
+
+// Julian day of 1 Muharram 1 AH in the civil (tabular) calendar.
+const epoch = 1948439.5
+
+// Julian day at the Unix epoch.
+const unixEpochJD = 2440587.5
+
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%d %s %d", d.Day, MonthNames[d.Month], d.Year)
+}
+
+// FromTime converts the calendar date of t using the arithmetical Islamic
+// calendar. It can differ by a day or two from sighting-based calendars.
+func FromTime(t time.Time) Date {
+	days := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+	jd := unixEpochJD + float64(days)
+
+	year := int(math.Floor((30*(jd-epoch) + 10646) / 10631))
+	month := min(12, int(math.Ceil((jd-(29+toJD(year, 1, 1)))/29.5))+1)
+	day := int(jd-toJD(year, month, 1)) + 1
+
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// Time returns midnight of the Gregorian day on which d falls.
+func (d Date) Time(loc *time.Location) time.Time {
+	days := int64(toJD(d.Year, d.Month, d.Day) - unixEpochJD)
+	t := time.Unix(days*86400, 0).UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// MonthLength returns how many days d's month has, 29 or 30, as the
+// arithmetical calendar counts them.
+func (d Date) MonthLength() int {
+	year, month := d.Year, d.Month+1
+	if month > 12 {
+		year, month = year+1, 1
+	}
+	return int(toJD(year, month, 1) - toJD(d.Year, d.Month, 1))
+}
+
+func FromAPI(h api.Hijri) (Date, bool) {
+	day, err := strconv.Atoi(strings.TrimSpace(h.Day))
+	if err != nil {
+		return Date{}, false
+	}
+	year, err := strconv.Atoi(strings.TrimSpace(h.Year))
+	if err != nil {
+		return Date{}, false
+	}
+	if h.Month.Number < 1 || h.Month.Number > 12 {
+		return Date{}, false
+	}
+	return Date{Year: year, Month: h.Month.Number, Day: day}, true
+}
+
+// MonthName returns the name of d's month, in Arabic if arabic is set.
+func (d Date) MonthName(arabic bool) string {
+	if arabic {
+		return arabicMonthNames[d.Month]
+	}
+	return MonthNames[d.Month]
+}
+
+// WeekdayName returns the Arabic name of a weekday, transliterated or in
+// Arabic script.
+func WeekdayName(w time.Weekday, arabic bool) string {
+	if arabic {
+		return arabicWeekdayNames[w]
+	}
+	return weekdayNames[w]
+}
+
+func toJD(year, month, day int) float64 {
+	return float64(day) +
+		math.Ceil(29.5*float64(month-1)) +
+		float64(year-1)*354 +
+		math.Floor(float64(3+11*year)/30) +
+		epoch - 1
+}
@@ -22,6 +22,26 @@ func Desktop(summary, body string) error {
 func Prayer(ev prayer.Event, hijri string) {
 	title := fmt.Sprintf("🕌 %s", ev.Name)
 	body := fmt.Sprintf("%s at %s", ev.Name, ev.When.Format(time.Kitchen))
+	if !ev.Khutbah.IsZero() {
+		body += fmt.Sprintf(", khutbah at %s", ev.Khutbah.Format(time.Kitchen))
+	}
+	if !ev.Iqamah.IsZero() {
+		body += fmt.Sprintf(", iqamah at %s", ev.Iqamah.Format(time.Kitchen))
+	}
+
+	if hijri != "" {
+		body = fmt.Sprintf("%s\n%s", hijri, body)
+	}
+
+	if err := Desktop(title, body); err != nil {
+		slog.Default().Debug("notification error", "error", err)
+	}
+}
+
+func Combined(c prayer.Combination, hijri string) {
+	title := fmt.Sprintf("🕌 %s", c.Name())
+	body := fmt.Sprintf("%s at %s — combine now or by %s",
+		c.First.Name, c.First.When.Format(time.Kitchen), c.Takhir.End.Format(time.Kitchen))
 
 	if hijri != "" {
 		body = fmt.Sprintf("%s\n%s", hijri, body)
@@ -31,3 +51,19 @@ func Prayer(ev prayer.Event, hijri string) {
 		slog.Default().Debug("notification error", "error", err)
 	}
 }
+
+func WindowEnding(w prayer.Window, left time.Duration) {
+	title := fmt.Sprintf("⏳ %s", w.Name)
+	body := fmt.Sprintf("%s window ends in %s (%s at %s)",
+		w.Name, prayer.HumanDuration(left), w.EndName, w.End.Format(time.Kitchen))
+
+	if err := Desktop(title, body); err != nil {
+		slog.Default().Debug("notification error", "error", err)
+	}
+}
+
+func Reminder(title, body string) {
+	if err := Desktop(title, body); err != nil {
+		slog.Default().Debug("notification error", "error", err)
+	}
+}
@@ -0,0 +1,76 @@
+package prayer
+
+import "fmt"
+
+// Combination is a pair of prayers a traveller may join, either early in
+// the first prayer's time (jam' taqdim) or late in the second's (jam'
+// ta'khir).
+type Combination struct {
+	First  Event
+	Second Event
+	Taqdim Window
+	Takhir Window
+}
+
+func (c Combination) Name() string {
+	return fmt.Sprintf("%s + %s", c.First.Name, c.Second.Name)
+}
+
+// Spans describes both ways of combining the pair, for display.
+func (c Combination) Spans(ampm bool) string {
+	return fmt.Sprintf("taqdim %s–%s, ta'khir %s–%s",
+		FormatTime(c.Taqdim.Start, ampm), FormatTime(c.Taqdim.End, ampm),
+		FormatTime(c.Takhir.Start, ampm), FormatTime(c.Takhir.End, ampm))
+}
+
+// Combinations returns today's Dhuhr+Asr and Maghrib+Isha pairs with the
+// span of each way of combining them. A traveller prays Dhuhr rather than
+// Jumu'ah, so Friday's midday prayer pairs with Asr as on other days.
+func Combinations(events []Event, ishaEnd IshaEnd) []Combination {
+	windows := Windows(events, ishaEnd)
+
+	var combos []Combination
+
+	dhuhr, hasDhuhr := findDhuhr(events)
+	asr, hasAsr := findEvent(events, "Asr")
+	if hasDhuhr && hasAsr {
+		if w, ok := findWindow(windows, asr.Name); ok {
+			combos = append(combos, Combination{
+				First:  dhuhr,
+				Second: asr,
+				Taqdim: Window{Name: dhuhr.Name, Start: dhuhr.When, End: asr.When, EndName: asr.Name},
+				Takhir: w,
+			})
+		}
+	}
+
+	maghrib, hasMaghrib := findEvent(events, "Maghrib")
+	isha, hasIsha := findEvent(events, "Isha")
+	if hasMaghrib && hasIsha {
+		if w, ok := findWindow(windows, isha.Name); ok {
+			combos = append(combos, Combination{
+				First:  maghrib,
+				Second: isha,
+				Taqdim: Window{Name: maghrib.Name, Start: maghrib.When, End: isha.When, EndName: isha.Name},
+				Takhir: w,
+			})
+		}
+	}
+
+	return combos
+}
+
+// Qasr reports whether a traveller shortens the prayer from four rak'ahs
+// to two. Jumu'ah is already two and Fajr and Maghrib are never shortened.
+func Qasr(name string) bool {
+	return name == "Dhuhr" || name == "Asr" || name == "Isha"
+}
+
+func findWindow(windows []Window, name string) (Window, bool) {
+	for _, w := range windows {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
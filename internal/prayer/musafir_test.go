@@ -0,0 +1,43 @@
+package prayer
+
+import "testing"
+
+func TestCombinations(t *testing.T) {
+	tests := []struct {
+		name   string
+		date   string
+		rename string
+		want   []string
+	}{
+		{name: "weekday", date: "2026-10-15", want: []string{"Dhuhr + Asr", "Maghrib + Isha"}},
+		{name: "friday as dhuhr", date: "2026-10-16", want: []string{"Dhuhr + Asr", "Maghrib + Isha"}},
+		{name: "friday as jumuah", date: "2026-10-16", rename: Jumuah, want: []string{"Jumu'ah + Asr", "Maghrib + Isha"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := schedule(t, tt.date, "05:10", "06:30", "12:05", "15:20", "17:45", "19:00")
+			if tt.rename != "" {
+				events[2].Name = tt.rename
+			}
+
+			combos := Combinations(events, IshaEndMidnight)
+			if len(combos) != len(tt.want) {
+				t.Fatalf("got %d combinations, want %v", len(combos), tt.want)
+			}
+			for i, c := range combos {
+				if c.Name() != tt.want[i] {
+					t.Errorf("combination %d is %q, want %q", i, c.Name(), tt.want[i])
+				}
+			}
+
+			dhuhrAsr := combos[0]
+			if clock(dhuhrAsr.Taqdim.Start) != "12:05" || clock(dhuhrAsr.Taqdim.End) != "15:20" {
+				t.Errorf("taqdim %s–%s, want 12:05–15:20", clock(dhuhrAsr.Taqdim.Start), clock(dhuhrAsr.Taqdim.End))
+			}
+			if clock(dhuhrAsr.Takhir.Start) != "15:20" || clock(dhuhrAsr.Takhir.End) != "17:45" {
+				t.Errorf("ta'khir %s–%s, want 15:20–17:45", clock(dhuhrAsr.Takhir.Start), clock(dhuhrAsr.Takhir.End))
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package prayer
+
+import "time"
+
+type FastingDay struct {
+	Suhoor     time.Time
+	SuhoorName string
+	Iftar      time.Time
+}
+
+// Fasting returns when suhoor ends (Imsak, or Fajr when the source has no
+// Imsak) and when to break the fast at Maghrib.
+func Fasting(imsak time.Time, events []Event) *FastingDay {
+	maghrib, ok := findEvent(events, "Maghrib")
+	if !ok {
+		return nil
+	}
+
+	day := &FastingDay{Iftar: maghrib.When}
+
+	if !imsak.IsZero() {
+		day.Suhoor = imsak
+		day.SuhoorName = "Imsak"
+	} else if fajr, ok := findEvent(events, "Fajr"); ok {
+		day.Suhoor = fajr.When
+		day.SuhoorName = "Fajr"
+	} else {
+		return nil
+	}
+
+	return day
+}
+
+// Countdown reports which of suhoor or iftar is next and the time left
+// until it. After iftar it counts down to tomorrow's suhoor, taken as a day
+// after today's; the two differ by about a minute.
+func (d *FastingDay) Countdown(now time.Time) (string, time.Duration) {
+	switch {
+	case now.Before(d.Suhoor):
+		return "Suhoor", d.Suhoor.Sub(now)
+	case now.Before(d.Iftar):
+		return "Iftar", d.Iftar.Sub(now)
+	}
+	return "Suhoor", d.Suhoor.Add(24 * time.Hour).Sub(now)
+}
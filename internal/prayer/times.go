@@ -2,105 +2,97 @@ package prayer
 
 import (
 	"fmt"
-	"log/slog"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/zizouhuweidi/adhanctl/internal/api"
+	"github.com/zizouhuweidi/adhanctl/internal/astro"
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
 )
 
 type Event struct {
-	Name string
-	When time.Time
+	Name    string
+	When    time.Time
+	Iqamah  time.Time
+	Khutbah time.Time
 }
 
+const Jumuah = "Jumu'ah"
+
 type PrayerOrder []string
 
 var StandardOrder PrayerOrder = []string{"Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"}
 
-func ParseTimes(resp *api.Response, loc *time.Location) []Event {
-	var events []Event
-
-	gregDate := resp.Data.Date.Gregorian.Date
-	if gregDate == "" {
-		now := time.Now().In(loc)
-		gregDate = now.Format("02-01-2006")
-	}
-
-	for _, name := range StandardOrder {
-		tstr, ok := resp.Data.Timings[name]
-		if !ok {
-			continue
-		}
+// ApplyJumuah renames Dhuhr to Jumu'ah on Fridays and records the
+// masjid's khutbah and iqamah times when those are set. When stays the
+// computed Dhuhr, so zawal and the prayer windows don't move.
+func ApplyJumuah(events []Event, khutbah, iqamah string) ([]Event, error) {
+	result := make([]Event, len(events))
+	copy(result, events)
 
-		tok := strings.Fields(tstr)
-		if len(tok) == 0 {
+	for i, e := range result {
+		if e.Name != "Dhuhr" || e.When.Weekday() != time.Friday {
 			continue
 		}
 
-		ts := tok[0]
-		if i := strings.Index(ts, "("); i >= 0 {
-			ts = strings.TrimSpace(ts[:i])
+		result[i].Name = Jumuah
+		if khutbah != "" {
+			t, err := ClockOn(e.When, khutbah)
+			if err != nil {
+				return events, fmt.Errorf("jumuah khutbah: %w", err)
+			}
+			result[i].Khutbah = t
 		}
-
-		dt, err := parseDateTime(gregDate, ts, loc)
-		if err != nil {
-			slog.Default().Debug("parse time error", "prayer", name, "error", err)
-			continue
+		if iqamah != "" {
+			t, err := ClockOn(e.When, iqamah)
+			if err != nil {
+				return events, fmt.Errorf("jumuah iqamah: %w", err)
+			}
+			result[i].Iqamah = t
 		}
-
-		events = append(events, Event{Name: name, When: dt})
 	}
 
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].When.Before(events[j].When)
-	})
-
-	return events
+	return result, nil
 }
 
-func parseDateTime(gregDate, timeStr string, loc *time.Location) (time.Time, error) {
-	parts := strings.Split(timeStr, ":")
-	if len(parts) < 2 {
-		return time.Time{}, fmt.Errorf("invalid time format: %s", timeStr)
+// ApplyElevation moves Sunrise earlier and Maghrib later by the horizon
+// dip an observer elevation metres up sees, for sources that compute
+// both for a flat horizon.
+func ApplyElevation(events []Event, lat, lon, elevation float64) []Event {
+	if elevation <= 0 || len(events) == 0 {
+		return events
 	}
 
-	h, _ := strconv.Atoi(parts[0])
-	m, _ := strconv.Atoi(parts[1])
+	day := astro.NewSolarDay(events[0].When, lat, lon)
+	day.Elevation = elevation
+	earlier := day.ElevationShift(false)
+	later := day.ElevationShift(true)
 
-	day, month, year := 0, 0, 0
-	dateParts := strings.Split(gregDate, "-")
-	if len(dateParts) >= 3 {
-		day, _ = strconv.Atoi(dateParts[0])
-		month, _ = strconv.Atoi(dateParts[1])
-		year, _ = strconv.Atoi(dateParts[2])
-	}
+	result := make([]Event, len(events))
+	copy(result, events)
 
-	if day == 0 || month == 0 || year == 0 {
-		now := time.Now().In(loc)
-		day = now.Day()
-		month = int(now.Month())
-		year = now.Year()
+	for i, e := range result {
+		switch e.Name {
+		case "Sunrise":
+			result[i].When = e.When.Add(-earlier)
+		case "Maghrib":
+			result[i].When = e.When.Add(later)
+		}
 	}
-
-	return time.Date(year, time.Month(month), day, h, m, 0, 0, loc), nil
+	return result
 }
 
-func TimezoneFromResp(resp *api.Response) *time.Location {
-	tz := resp.Data.Meta.Timezone
-	if tz == "" {
-		return time.Local
-	}
+func IsDhuhr(name string) bool {
+	return name == "Dhuhr" || name == Jumuah
+}
 
-	loc, err := time.LoadLocation(tz)
+// ClockOn returns the given HH:MM wall-clock time on day's date.
+func ClockOn(day time.Time, clock string) (time.Time, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
 	if err != nil {
-		slog.Default().Debug("unknown timezone", "tz", tz, "error", err)
-		return time.Local
+		return time.Time{}, fmt.Errorf("invalid time %q: use HH:MM", clock)
 	}
-
-	return loc
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
 }
 
 func NextEventAfter(events []Event, after time.Time) *Event {
@@ -133,6 +125,150 @@ func UpcomingEvents(events []Event, from time.Time, within time.Duration) []Even
 	return result
 }
 
+type Window struct {
+	Name    string
+	Start   time.Time
+	End     time.Time
+	EndName string
+}
+
+func (w Window) Remaining(now time.Time) time.Duration {
+	return w.End.Sub(now)
+}
+
+type IshaEnd string
+
+const (
+	IshaEndMidnight IshaEnd = "midnight"
+	IshaEndFajr     IshaEnd = "fajr"
+)
+
+func ParseIshaEnd(s string) (IshaEnd, error) {
+	switch IshaEnd(strings.ToLower(strings.TrimSpace(s))) {
+	case IshaEndMidnight, "":
+		return IshaEndMidnight, nil
+	case IshaEndFajr:
+		return IshaEndFajr, nil
+	}
+	return "", fmt.Errorf("invalid isha end %q: use midnight or fajr", s)
+}
+
+// Windows returns the time span of each prayer. Sunrise only closes Fajr,
+// and Isha ends at midnight (halfway from Maghrib to sunrise) or next Fajr.
+func Windows(events []Event, ishaEnd IshaEnd) []Window {
+	var windows []Window
+
+	for i, e := range events {
+		if e.Name == "Sunrise" {
+			continue
+		}
+
+		if e.Name == "Isha" {
+			end, endName, ok := ishaWindowEnd(events, ishaEnd)
+			if ok {
+				windows = append(windows, Window{Name: e.Name, Start: e.When, End: end, EndName: endName})
+			}
+			continue
+		}
+
+		if i+1 < len(events) {
+			next := events[i+1]
+			windows = append(windows, Window{Name: e.Name, Start: e.When, End: next.When, EndName: next.Name})
+		}
+	}
+
+	return windows
+}
+
+func CurrentWindow(events []Event, now time.Time, ishaEnd IshaEnd) *Window {
+	windows := Windows(events, ishaEnd)
+
+	// Before Fajr we may still be inside last night's Isha, which today's
+	// schedule approximates well enough.
+	for _, w := range windows {
+		if w.Name == "Isha" {
+			windows = append(windows, Window{
+				Name:    w.Name,
+				Start:   w.Start.Add(-24 * time.Hour),
+				End:     w.End.Add(-24 * time.Hour),
+				EndName: w.EndName,
+			})
+		}
+	}
+
+	return ActiveWindow(windows, now)
+}
+
+func ishaWindowEnd(events []Event, ishaEnd IshaEnd) (time.Time, string, bool) {
+	fajr, hasFajr := findEvent(events, "Fajr")
+
+	if ishaEnd == IshaEndMidnight {
+		sunrise, hasSunrise := findEvent(events, "Sunrise")
+		maghrib, hasMaghrib := findEvent(events, "Maghrib")
+		if hasSunrise && hasMaghrib {
+			night := sunrise.When.Add(24 * time.Hour).Sub(maghrib.When)
+			return maghrib.When.Add(night / 2), "Midnight", true
+		}
+	}
+
+	if hasFajr {
+		return fajr.When.Add(24 * time.Hour), "Fajr", true
+	}
+	return time.Time{}, "", false
+}
+
+type MakruhMargins struct {
+	Sunrise time.Duration
+	Zawal   time.Duration
+	Sunset  time.Duration
+}
+
+// MakruhWindows returns the times when voluntary prayer is disliked: just
+// after sunrise, the zawal before Dhuhr, and from Asr yellowing to sunset.
+func MakruhWindows(events []Event, m MakruhMargins) []Window {
+	var windows []Window
+
+	if e, ok := findEvent(events, "Sunrise"); ok && m.Sunrise > 0 {
+		windows = append(windows, Window{Name: "Sunrise", Start: e.When, End: e.When.Add(m.Sunrise), EndName: "Duha"})
+	}
+	if e, ok := findDhuhr(events); ok && m.Zawal > 0 {
+		windows = append(windows, Window{Name: "Zawal", Start: e.When.Add(-m.Zawal), End: e.When, EndName: e.Name})
+	}
+	if e, ok := findEvent(events, "Maghrib"); ok && m.Sunset > 0 {
+		windows = append(windows, Window{Name: "Sunset", Start: e.When.Add(-m.Sunset), End: e.When, EndName: e.Name})
+	}
+
+	return windows
+}
+
+func ActiveWindow(windows []Window, now time.Time) *Window {
+	for _, w := range windows {
+		if !now.Before(w.Start) && now.Before(w.End) {
+			cp := w
+			return &cp
+		}
+	}
+	return nil
+}
+
+func findDhuhr(events []Event) (Event, bool) {
+	for _, e := range events {
+		if IsDhuhr(e.Name) {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
+func findEvent(events []Event, name string) (Event, bool) {
+	for _, e := range events {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
 func HumanDuration(d time.Duration) string {
 	if d < 0 {
 		return "passed"
@@ -152,32 +288,13 @@ func HumanDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh%02dm", h, m)
 }
 
-func HijriString(resp *api.Response, arabic bool) string {
-	h := resp.Data.Date.Hijri
-
-	var parts []string
-
-	if h.Date != "" {
-		parts = append(parts, h.Date)
-	}
-
-	month := h.Month.En
-	if arabic {
-		month = h.Month.Ar
+// HijriString formats a Hijri date with its month and weekday names, such
+// as "05-09-1447 Ramadan Al Juma'a".
+func HijriString(h hijri.Date, weekday time.Weekday, arabic bool) string {
+	if h.Year == 0 {
+		return ""
 	}
-	if month != "" {
-		parts = append(parts, month)
-	}
-
-	weekday := h.Weekday.En
-	if arabic {
-		weekday = h.Weekday.Ar
-	}
-	if weekday != "" {
-		parts = append(parts, weekday)
-	}
-
-	return strings.Join(parts, " ")
+	return fmt.Sprintf("%02d-%02d-%d %s %s", h.Day, h.Month, h.Year, h.MonthName(arabic), hijri.WeekdayName(weekday, arabic))
 }
 
 func FormatTime(t time.Time, ampm bool) string {
@@ -0,0 +1,114 @@
+package prayer
+
+import (
+	"testing"
+	"time"
+)
+
+// schedule returns a day of events at the given HH:MM times, in
+// StandardOrder.
+func schedule(t *testing.T, date string, clocks ...string) []Event {
+	t.Helper()
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	for i, c := range clocks {
+		when, err := ClockOn(day, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, Event{Name: StandardOrder[i], When: when})
+	}
+	return events
+}
+
+func TestApplyJumuah(t *testing.T) {
+	tests := []struct {
+		name      string
+		date      string
+		khutbah   string
+		iqamah    string
+		want      string
+		khutbahAt string
+		iqamahAt  string
+		wantErr   bool
+	}{
+		{name: "friday with masjid times", date: "2026-10-16", khutbah: "13:15", iqamah: "13:45",
+			want: Jumuah, khutbahAt: "13:15", iqamahAt: "13:45"},
+		{name: "friday without masjid times", date: "2026-10-16", want: Jumuah},
+		{name: "thursday", date: "2026-10-15", khutbah: "13:15", iqamah: "13:45", want: "Dhuhr"},
+		{name: "bad khutbah", date: "2026-10-16", khutbah: "1:15pm", wantErr: true},
+		{name: "bad iqamah", date: "2026-10-16", iqamah: "noon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := schedule(t, tt.date, "05:10", "06:30", "12:05", "15:20", "17:45", "19:00")
+			got, err := ApplyJumuah(events, tt.khutbah, tt.iqamah)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyJumuah error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			dhuhr := got[2]
+			if dhuhr.Name != tt.want {
+				t.Errorf("got name %q, want %q", dhuhr.Name, tt.want)
+			}
+			if !dhuhr.When.Equal(events[2].When) {
+				t.Errorf("When moved to %s, want the computed %s", dhuhr.When, events[2].When)
+			}
+			if s := clock(dhuhr.Khutbah); s != tt.khutbahAt {
+				t.Errorf("got khutbah %q, want %q", s, tt.khutbahAt)
+			}
+			if s := clock(dhuhr.Iqamah); s != tt.iqamahAt {
+				t.Errorf("got iqamah %q, want %q", s, tt.iqamahAt)
+			}
+		})
+	}
+}
+
+// TestJumuahKeepsWindows checks that a khutbah time doesn't move the
+// zawal or the prayer windows on either side of Jumu'ah.
+func TestJumuahKeepsWindows(t *testing.T) {
+	events := schedule(t, "2026-10-16", "05:10", "06:30", "12:05", "15:20", "17:45", "19:00")
+	jumuah, err := ApplyJumuah(events, "13:15", "13:45")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	margins := MakruhMargins{Zawal: 10 * time.Minute}
+	before, after := MakruhWindows(events, margins), MakruhWindows(jumuah, margins)
+	if len(after) != 1 || !after[0].Start.Equal(before[0].Start) || !after[0].End.Equal(before[0].End) {
+		t.Errorf("zawal moved from %v to %v", before, after)
+	}
+
+	windows := Windows(jumuah, IshaEndFajr)
+	want := map[string][2]string{
+		Jumuah: {"12:05", "15:20"},
+		"Asr":  {"15:20", "17:45"},
+	}
+	for _, w := range windows {
+		span, ok := want[w.Name]
+		if !ok {
+			continue
+		}
+		if clock(w.Start) != span[0] || clock(w.End) != span[1] {
+			t.Errorf("%s window %s–%s, want %s–%s", w.Name, clock(w.Start), clock(w.End), span[0], span[1])
+		}
+		delete(want, w.Name)
+	}
+	for name := range want {
+		t.Errorf("no %s window", name)
+	}
+}
+
+func clock(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("15:04")
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/api"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
+)
+
+// AlAdhan fetches times from the AlAdhan API.
+type AlAdhan struct {
+	Client *api.Client
+}
+
+func NewAlAdhan(client *api.Client) *AlAdhan {
+	return &AlAdhan{Client: client}
+}
+
+func (a *AlAdhan) Name() string { return "aladhan" }
+
+func (a *AlAdhan) Day(ctx context.Context, q Query) (*Day, error) {
+	resp, err := a.Client.FetchTimings(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return fromResponse(resp, q), nil
+}
+
+// fromResponse reads an API response into a Day. Timings are "HH:MM",
+// sometimes followed by the zone, in Meta.Timezone on the response's
+// Gregorian date.
+func fromResponse(resp *api.Response, q Query) *Day {
+	meta := resp.Data.Meta
+	loc := responseLocation(meta)
+
+	date := dateIn(q, loc)
+	if t, err := time.ParseInLocation("02-01-2006", resp.Data.Date.Gregorian.Date, loc); err == nil {
+		date = t
+	}
+
+	day := newDay(date, place{meta.Latitude, meta.Longitude, loc})
+	if h, ok := hijri.FromAPI(resp.Data.Date.Hijri); ok {
+		day.Hijri = h
+	}
+
+	timings := make(map[string]string, len(resp.Data.Timings))
+	for name, value := range resp.Data.Timings {
+		if i := strings.IndexAny(value, " ("); i >= 0 {
+			value = value[:i]
+		}
+		timings[name] = value
+	}
+	day.addEvents(clocks(date, timings), nil)
+	return day
+}
+
+// responseLocation returns the response's timezone, or the one at its
+// coordinates when the zone is missing or unknown here.
+func responseLocation(meta api.Meta) *time.Location {
+	if meta.Timezone != "" {
+		loc, err := time.LoadLocation(meta.Timezone)
+		if err == nil {
+			return loc
+		}
+		slog.Default().Debug("unknown timezone", "tz", meta.Timezone, "error", err)
+	}
+
+	if meta.Latitude != 0 || meta.Longitude != 0 {
+		loc := geo.LocationAt(meta.Latitude, meta.Longitude)
+		slog.Default().Debug("timezone from coordinates", "tz", loc)
+		return loc
+	}
+
+	return time.Local
+}
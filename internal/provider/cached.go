@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/cache"
+)
+
+// Cached answers from the cache when it has the day and otherwise asks the
+// provider it wraps, storing what comes back.
+type Cached struct {
+	Provider Provider
+	Cache    *cache.Cache
+}
+
+func NewCached(p Provider, c *cache.Cache) *Cached {
+	return &Cached{Provider: p, Cache: c}
+}
+
+func (c *Cached) Name() string { return c.Provider.Name() }
+
+func (c *Cached) Day(ctx context.Context, q Query) (*Day, error) {
+	var entry cachedDay
+	if c.Cache.Get(q, &entry) {
+		if day, ok := entry.day(); ok {
+			return day, nil
+		}
+	}
+
+	day, err := c.Provider.Day(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = c.Cache.Set(q, cachedDay{Timezone: day.Location().String(), Day: *day})
+	return day, nil
+}
+
+// cachedDay is a Day as the cache stores it. JSON keeps a time's offset
+// but not its zone, so the zone is stored by name and put back on load.
+type cachedDay struct {
+	Timezone string
+	Day
+}
+
+// day returns the stored Day in its own zone. Entries written before Day
+// had its own format have no zone and count as misses.
+func (e cachedDay) day() (*Day, bool) {
+	if e.Timezone == "" || e.Date.IsZero() {
+		return nil, false
+	}
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return nil, false
+	}
+
+	day := e.Day
+	day.Date = day.Date.In(loc)
+	day.Imsak = inZone(day.Imsak, loc)
+	for i := range day.Events {
+		ev := &day.Events[i]
+		ev.When = inZone(ev.When, loc)
+		ev.Iqamah = inZone(ev.Iqamah, loc)
+		ev.Khutbah = inZone(ev.Khutbah, loc)
+	}
+	return &day, true
+}
+
+// inZone is t in loc, leaving the zero time as it is.
+func inZone(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(loc)
+}
@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/astro"
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
+)
+
+// Method holds the parameters of a calculation method.
+type Method struct {
+	// FajrAngle and IshaAngle are the sun's depression below the horizon,
+	// in degrees, at Fajr and Isha.
+	FajrAngle float64
+	IshaAngle float64
+
+	// IshaAfter, when set, puts Isha a fixed time after Maghrib instead,
+	// with IshaRamadan used during Ramadan if it is set too.
+	IshaAfter   time.Duration
+	IshaRamadan time.Duration
+
+	// MaghribAngle, when set, puts Maghrib at this depression rather than
+	// at sunset.
+	MaghribAngle float64
+}
+
+// Methods are the calculation methods Local supports, by AlAdhan method
+// number.
+var Methods = map[int]Method{
+	0:  {FajrAngle: 16, IshaAngle: 14, MaghribAngle: 4},
+	1:  {FajrAngle: 18, IshaAngle: 18},
+	2:  {FajrAngle: 15, IshaAngle: 15},
+	3:  {FajrAngle: 18, IshaAngle: 17},
+	4:  {FajrAngle: 18.5, IshaAfter: 90 * time.Minute, IshaRamadan: 120 * time.Minute},
+	5:  {FajrAngle: 19.5, IshaAngle: 17.5},
+	7:  {FajrAngle: 17.7, IshaAngle: 14, MaghribAngle: 4.5},
+	8:  {FajrAngle: 19.5, IshaAfter: 90 * time.Minute},
+	9:  {FajrAngle: 18, IshaAngle: 17.5},
+	10: {FajrAngle: 18, IshaAfter: 90 * time.Minute},
+	11: {FajrAngle: 20, IshaAngle: 18},
+	12: {FajrAngle: 12, IshaAngle: 12},
+	13: {FajrAngle: 18, IshaAngle: 17},
+	14: {FajrAngle: 16, IshaAngle: 15},
+	15: {FajrAngle: 18, IshaAngle: 18},
+	16: {FajrAngle: 18.2, IshaAngle: 18.2},
+	17: {FajrAngle: 20, IshaAngle: 18},
+	18: {FajrAngle: 18, IshaAngle: 18},
+	19: {FajrAngle: 18, IshaAngle: 17},
+	20: {FajrAngle: 20, IshaAngle: 18},
+	21: {FajrAngle: 19, IshaAngle: 17},
+	22: {FajrAngle: 18, IshaAfter: 77 * time.Minute},
+	23: {FajrAngle: 18, IshaAngle: 18},
+}
+
+// imsakBefore is how long before Fajr Imsak falls, as AlAdhan gives it.
+const imsakBefore = 10 * time.Minute
+
+// Local calculates prayer times on this machine from the sun's position,
+// so it needs no network. Locations must be coordinates or cities in the
+// built-in gazetteer.
+type Local struct{}
+
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (l *Local) Name() string { return "local" }
+
+func (l *Local) Day(ctx context.Context, q Query) (*Day, error) {
+	m, ok := Methods[q.Method]
+	if !ok {
+		return nil, fmt.Errorf("method %d can't be calculated locally", q.Method)
+	}
+
+	p, err := locate(q)
+	if err != nil {
+		return nil, err
+	}
+
+	date := dateIn(q, p.Location)
+	day := newDay(date, p)
+	times, err := Calculate(date, p.Latitude, p.Longitude, m, q.School, day.Hijri.Month == hijri.Ramadan)
+	if err != nil {
+		return nil, err
+	}
+	day.addEvents(times, nil)
+	return day, nil
+}
+
+// Calculate returns the times for date at a location, rounded to the
+// minute. school 1 is Hanafi, which starts Asr later. Where the sun never
+// gets low enough for Fajr or Isha, they are limited to a share of the
+// night in proportion to the angle, like AlAdhan's default angle-based
+// adjustment.
+func Calculate(date time.Time, lat, lon float64, m Method, school int, ramadan bool) (map[string]time.Time, error) {
+	sd := astro.NewSolarDay(date, lat, lon)
+
+	sunrise, ok1 := sd.TimeAtAltitude(astro.AltitudeSunrise, false)
+	sunset, ok2 := sd.TimeAtAltitude(astro.AltitudeSunrise, true)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("the sun doesn't rise and set here on %s", date.Format("2 Jan 2006"))
+	}
+
+	dhuhr := sd.Noon()
+	night := 24*time.Hour - sunset.Sub(sunrise)
+
+	fajr, ok := sd.TimeAtAltitude(-m.FajrAngle, false)
+	if limit := sunrise.Add(-nightShare(m.FajrAngle, night)); !ok || fajr.Before(limit) {
+		fajr = limit
+	}
+
+	maghrib := sunset
+	if m.MaghribAngle > 0 {
+		if t, ok := sd.TimeAtAltitude(-m.MaghribAngle, true); ok {
+			maghrib = t
+		}
+	}
+
+	var isha time.Time
+	switch {
+	case m.IshaAfter > 0 && ramadan && m.IshaRamadan > 0:
+		isha = maghrib.Add(m.IshaRamadan)
+	case m.IshaAfter > 0:
+		isha = maghrib.Add(m.IshaAfter)
+	default:
+		isha, ok = sd.TimeAtAltitude(-m.IshaAngle, true)
+		if limit := sunset.Add(nightShare(m.IshaAngle, night)); !ok || isha.After(limit) {
+			isha = limit
+		}
+	}
+
+	shadow := 1.0
+	if school == 1 {
+		shadow = 2
+	}
+	decl := astro.Declination(dhuhr)
+	asrAltitude := degrees(math.Atan(1 / (shadow + math.Tan(radians(math.Abs(lat-decl))))))
+	asr, ok := sd.TimeAtAltitude(asrAltitude, true)
+	if !ok {
+		return nil, fmt.Errorf("no Asr time here on %s", date.Format("2 Jan 2006"))
+	}
+
+	// Midnight is halfway from sunset to the next Fajr, which is close
+	// enough to a day after this one.
+	midnight := sunset.Add(fajr.Add(24*time.Hour).Sub(sunset) / 2)
+
+	times := map[string]time.Time{
+		"Imsak":    fajr.Add(-imsakBefore),
+		"Fajr":     fajr,
+		"Sunrise":  sunrise,
+		"Dhuhr":    dhuhr,
+		"Asr":      asr,
+		"Sunset":   sunset,
+		"Maghrib":  maghrib,
+		"Isha":     isha,
+		"Midnight": midnight,
+	}
+	for name, t := range times {
+		times[name] = t.Round(time.Minute)
+	}
+	return times, nil
+}
+
+// nightShare is the part of the night given to twilight at angle degrees.
+func nightShare(angle float64, night time.Duration) time.Duration {
+	return time.Duration(float64(night) * angle / 60)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
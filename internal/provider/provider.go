@@ -0,0 +1,141 @@
+// Package provider abstracts where prayer times come from: the AlAdhan
+// API, a local calculation, or a mosque's published timetable.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/api"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
+	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+)
+
+// Day is one day of prayer times at a place. Every provider returns this,
+// so nothing past the provider depends on where the times came from.
+type Day struct {
+	// Date is midnight of the day, in the place's timezone.
+	Date      time.Time
+	Latitude  float64
+	Longitude float64
+
+	// Hijri is the Hijri date the provider gives for Date.
+	Hijri hijri.Date
+
+	// Events are the prayers and sunrise in time order, with iqamah
+	// times where the provider has them.
+	Events []prayer.Event
+
+	// Imsak is when suhoor ends, or zero if the provider has none.
+	Imsak time.Time
+}
+
+// Location returns the place's timezone.
+func (d *Day) Location() *time.Location {
+	return d.Date.Location()
+}
+
+// HasCoordinates reports whether the provider knew where the place is.
+func (d *Day) HasCoordinates() bool {
+	return d.Latitude != 0 || d.Longitude != 0
+}
+
+// Query asks for one day at one location, with the method and school to
+// use where the provider calculates.
+type Query = api.TimingsParams
+
+// Provider returns the prayer times for a day.
+type Provider interface {
+	// Name identifies the provider in logs and the source config key.
+	Name() string
+	Day(ctx context.Context, q Query) (*Day, error)
+}
+
+// place is where a query is for, as far as it can be worked out offline.
+type place struct {
+	Latitude  float64
+	Longitude float64
+	Location  *time.Location
+}
+
+// locate resolves q's location through the gazetteer. Addresses are tried
+// as city names.
+func locate(q Query) (place, error) {
+	if q.Latitude != 0 && q.Longitude != 0 {
+		return place{q.Latitude, q.Longitude, geo.LocationAt(q.Latitude, q.Longitude)}, nil
+	}
+
+	name := q.City
+	if name == "" {
+		name = q.Address
+	}
+	if name == "" {
+		return place{}, fmt.Errorf("no location: set a city or coordinates")
+	}
+
+	city, ok := geo.LookupCity(name, q.Country)
+	if !ok {
+		return place{}, fmt.Errorf("%q is not in the built-in city list: set latitude and longitude instead", name)
+	}
+
+	loc, err := time.LoadLocation(city.Timezone)
+	if err != nil {
+		loc = geo.LocationAt(city.Latitude, city.Longitude)
+	}
+	return place{city.Latitude, city.Longitude, loc}, nil
+}
+
+// newDay returns a Day for date at p with no events yet.
+func newDay(date time.Time, p place) *Day {
+	return &Day{
+		Date:      date,
+		Latitude:  p.Latitude,
+		Longitude: p.Longitude,
+		Hijri:     hijri.FromTime(date),
+	}
+}
+
+// addEvents sets d's events from times by name, in time order, and its
+// Imsak. Other names are left out.
+func (d *Day) addEvents(times map[string]time.Time, iqamah map[string]time.Time) {
+	for _, name := range prayer.StandardOrder {
+		t, ok := times[name]
+		if !ok {
+			continue
+		}
+		d.Events = append(d.Events, prayer.Event{Name: name, When: t, Iqamah: iqamah[name]})
+	}
+	sort.Slice(d.Events, func(i, j int) bool {
+		return d.Events[i].When.Before(d.Events[j].When)
+	})
+	d.Imsak = times["Imsak"]
+}
+
+// clocks reads "HH:MM" values as times on day's date. Values that aren't
+// clock times are left out.
+func clocks(day time.Time, values map[string]string) map[string]time.Time {
+	times := make(map[string]time.Time, len(values))
+	for name, value := range values {
+		t, err := prayer.ClockOn(day, value)
+		if err != nil {
+			slog.Default().Debug("parse time error", "prayer", name, "error", err)
+			continue
+		}
+		times[name] = t
+	}
+	return times
+}
+
+// dateIn returns midnight of q's date in loc.
+func dateIn(q Query, loc *time.Location) time.Time {
+	d := q.Date
+	if d.IsZero() {
+		d = time.Now()
+	}
+	d = d.In(loc)
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+}
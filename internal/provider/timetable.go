@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/zizouhuweidi/adhanctl/internal/timetable"
+)
+
+// ErrNoTimes is returned when a timetable has no row for the date asked
+// and there is no fallback.
+var ErrNoTimes = errors.New("no times in the timetable")
+
+// Timetable gives the exact times from a mosque timetable imported with
+// `adhanctl timetable import`. The location in the query only sets the
+// timezone and coordinates; it has no effect on the times.
+type Timetable struct {
+	Path string
+
+	// Fallback, if set, answers for dates the timetable doesn't have,
+	// with a warning logged once per date.
+	Fallback Provider
+
+	warned map[string]bool
+}
+
+// NewTimetable reads the timetable at path, or the default one if path is
+// empty.
+func NewTimetable(path string, fallback Provider) *Timetable {
+	if path == "" {
+		path = timetable.DefaultPath()
+	}
+	return &Timetable{Path: path, Fallback: fallback, warned: make(map[string]bool)}
+}
+
+func (t *Timetable) Name() string { return "timetable" }
+
+func (t *Timetable) Day(ctx context.Context, q Query) (*Day, error) {
+	p, err := locate(q)
+	if err != nil {
+		p = place{Location: time.Local}
+	}
+	date := dateIn(q, p.Location)
+
+	rows, err := timetable.Read(t.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	row, ok := timetable.Lookup(rows, date)
+	if !ok {
+		day := date.Format("2006-01-02")
+		if t.Fallback == nil {
+			return nil, fmt.Errorf("%w for %s in %s", ErrNoTimes, day, t.Path)
+		}
+		if !t.warned[day] {
+			slog.Warn("timetable has no times for this date", "date", day, "path", t.Path, "using", t.Fallback.Name())
+			t.warned[day] = true
+		}
+		return t.Fallback.Day(ctx, q)
+	}
+
+	day := newDay(date, p)
+	day.addEvents(clocks(date, row.Adhan), clocks(date, row.Iqamah))
+	return day, nil
+}
@@ -0,0 +1,171 @@
+package reminders
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zizouhuweidi/adhanctl/internal/hijri"
+)
+
+const (
+	DataDirName = "adhanctl"
+	FileName    = "reminders"
+)
+
+type Reminder struct {
+	Day   int
+	Month int
+	Text  string
+}
+
+func (r Reminder) Date() string {
+	return fmt.Sprintf("%d %s", r.Day, hijri.MonthNames[r.Month])
+}
+
+func Path() string {
+	var base string
+	if x := os.Getenv("XDG_DATA_HOME"); x != "" {
+		base = filepath.Join(x, DataDirName)
+	} else {
+		home := os.Getenv("HOME")
+		if home == "" {
+			home = "."
+		}
+		base = filepath.Join(home, ".local", "share", DataDirName)
+	}
+	return filepath.Join(base, FileName)
+}
+
+// ParseHijri parses a day-month Hijri date such as "10-8" for 10 Sha'ban.
+func ParseHijri(s string) (day, month int, err error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid hijri date %q: use DAY-MONTH, e.g. 10-8", s)
+	}
+
+	day, err = strconv.Atoi(parts[0])
+	if err != nil || day < 1 || day > 30 {
+		return 0, 0, fmt.Errorf("invalid hijri day in %q: must be 1-30", s)
+	}
+	month, err = strconv.Atoi(parts[1])
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("invalid hijri month in %q: must be 1-12", s)
+	}
+
+	return day, month, nil
+}
+
+func readLines() ([]string, error) {
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading reminders: %w", err)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, nil
+}
+
+// parseLine returns the reminder on a line of the file, if it holds one.
+func parseLine(line string) (Reminder, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Reminder{}, false
+	}
+
+	date, text, ok := strings.Cut(line, "=")
+	if !ok {
+		return Reminder{}, false
+	}
+
+	day, month, err := ParseHijri(date)
+	if err != nil {
+		return Reminder{}, false
+	}
+	return Reminder{Day: day, Month: month, Text: strings.TrimSpace(text)}, true
+}
+
+func Load() ([]Reminder, error) {
+	lines, err := readLines()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Reminder
+	for _, line := range lines {
+		if r, ok := parseLine(line); ok {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// Save writes rs to the reminders file. Lines of reminders still in rs,
+// comments, blank lines and lines it can't read are kept as the user wrote
+// them; removed reminders are dropped and new ones added at the end.
+func Save(rs []Reminder) error {
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+
+	lines, err := readLines()
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		lines = []string{
+			"# adhanctl reminders",
+			"# DAY-MONTH (Hijri) = text, managed by 'adhanctl remind'",
+			"",
+		}
+	}
+
+	pending := append([]Reminder(nil), rs...)
+	var sb strings.Builder
+	for _, line := range lines {
+		if r, ok := parseLine(line); ok {
+			i := slices.Index(pending, r)
+			if i < 0 {
+				continue
+			}
+			pending = slices.Delete(pending, i, i+1)
+		}
+		sb.WriteString(line + "\n")
+	}
+	for _, r := range pending {
+		fmt.Fprintf(&sb, "%d-%d = %s\n", r.Day, r.Month, r.Text)
+	}
+
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf("writing reminders: %w", err)
+	}
+	return nil
+}
+
+// Due returns the reminders for h. A reminder for the 30th comes on the
+// 29th in months that end there, so it still fires every year.
+func Due(rs []Reminder, h hijri.Date) []Reminder {
+	last := h.MonthLength()
+	var result []Reminder
+	for _, r := range rs {
+		if r.Month != h.Month {
+			continue
+		}
+		if r.Day == h.Day || (r.Day > last && h.Day == last) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,487 @@
+package timetable
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options controls how a file is read. Adhan and Iqamah map a prayer name
+// to the column holding its times, by header or 1-based number, or for ICS
+// files to the event summary. Prayers left out are recognised from
+// the headers or summaries.
+type Options struct {
+	Adhan  map[string]string
+	Iqamah map[string]string
+
+	// DateColumn is the CSV column with the date, found from the header
+	// when empty.
+	DateColumn string
+
+	// DateFormat spells the CSV date format with YYYY, MM, M, DD, D, MMM
+	// (Mar) and MMMM (March), e.g. "DD/MM/YYYY". Common formats are
+	// tried, day first, when it is empty.
+	DateFormat string
+
+	// Location is the timezone ICS times in UTC are converted to.
+	Location *time.Location
+}
+
+// Mapping reports which column or summary each time was read from.
+type Mapping struct {
+	Date   string
+	Adhan  map[string]string
+	Iqamah map[string]string
+}
+
+// prayerNames are the spellings recognised in headers and summaries.
+var prayerNames = map[string][]string{
+	"Imsak":   {"imsak", "sehri", "sehar", "suhoor"},
+	"Fajr":    {"fajr", "fajar", "subh", "subuh"},
+	"Sunrise": {"sunrise", "shuruq", "shurooq", "shuruk"},
+	"Dhuhr":   {"dhuhr", "zuhr", "zohr", "duhr", "dhuhur", "zuhur", "dhuhar"},
+	"Asr":     {"asr", "asar"},
+	"Maghrib": {"maghrib", "magrib", "maghreb"},
+	"Isha":    {"isha", "esha", "ishaa"},
+}
+
+// iqamahWords mark a header or summary as a congregation time.
+var iqamahWords = []string{"iqamah", "iqama", "iqamat", "jamaat", "jamat", "jama'ah", "jamaah", "congregation"}
+
+// Import reads a CSV or ICS timetable, chosen by the file's extension.
+func Import(path string, opts Options) ([]Row, Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, Mapping{}, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics", ".ical", ".ifb":
+		return ImportICS(f, opts)
+	default:
+		return ImportCSV(f, opts)
+	}
+}
+
+// ImportCSV reads a timetable with a header row and one row per day.
+// Times may be 12-hour without am or pm, as printed timetables often are:
+// Dhuhr before 10:00 and Asr, Maghrib and Isha before 12:00 are taken as
+// afternoon times.
+func ImportCSV(r io.Reader, opts Options) ([]Row, Mapping, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, Mapping{}, fmt.Errorf("reading CSV: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, Mapping{}, fmt.Errorf("expected a header row and at least one day")
+	}
+	header := records[0]
+
+	m := Mapping{Adhan: make(map[string]string), Iqamah: make(map[string]string)}
+	adhanCols := make(map[string]int)
+	iqamahCols := make(map[string]int)
+
+	dateCol := -1
+	if opts.DateColumn != "" {
+		if dateCol, err = findColumn(header, opts.DateColumn); err != nil {
+			return nil, m, err
+		}
+	}
+	for prayer, col := range opts.Adhan {
+		i, err := findColumn(header, col)
+		if err != nil {
+			return nil, m, err
+		}
+		adhanCols[prayer] = i
+	}
+	for prayer, col := range opts.Iqamah {
+		i, err := findColumn(header, col)
+		if err != nil {
+			return nil, m, err
+		}
+		iqamahCols[prayer] = i
+	}
+
+	used := make(map[int]bool)
+	for _, i := range adhanCols {
+		used[i] = true
+	}
+	for _, i := range iqamahCols {
+		used[i] = true
+	}
+	for i, h := range header {
+		if used[i] {
+			continue
+		}
+		if dateCol < 0 && isDateHeader(h) {
+			dateCol = i
+			continue
+		}
+		prayer, iqamah, ok := recognise(h)
+		if !ok {
+			continue
+		}
+		cols := adhanCols
+		if iqamah {
+			cols = iqamahCols
+		}
+		if _, taken := cols[prayer]; !taken {
+			cols[prayer] = i
+		}
+	}
+
+	if dateCol < 0 {
+		return nil, m, fmt.Errorf("no date column found; name it with --date")
+	}
+	if len(adhanCols) == 0 && len(iqamahCols) == 0 {
+		return nil, m, fmt.Errorf("no prayer columns found; map them with --adhan PRAYER=COLUMN")
+	}
+
+	m.Date = header[dateCol]
+	for p, i := range adhanCols {
+		m.Adhan[p] = header[i]
+	}
+	for p, i := range iqamahCols {
+		m.Iqamah[p] = header[i]
+	}
+
+	parseDate := dateParser(opts.DateFormat)
+
+	var rows []Row
+	for n, rec := range records[1:] {
+		line := n + 2
+		if dateCol >= len(rec) || strings.TrimSpace(rec[dateCol]) == "" {
+			continue
+		}
+		date, err := parseDate(strings.TrimSpace(rec[dateCol]))
+		if err != nil {
+			return nil, m, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := newRow(date)
+		for p, i := range adhanCols {
+			if err := setTime(row.Adhan, p, rec, i); err != nil {
+				return nil, m, fmt.Errorf("line %d: %s: %w", line, p, err)
+			}
+		}
+		for p, i := range iqamahCols {
+			if err := setTime(row.Iqamah, p, rec, i); err != nil {
+				return nil, m, fmt.Errorf("line %d: %s iqamah: %w", line, p, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, m, fmt.Errorf("no days found")
+	}
+	return rows, m, nil
+}
+
+func setTime(times map[string]string, prayer string, rec []string, i int) error {
+	if i >= len(rec) || strings.TrimSpace(rec[i]) == "" {
+		return nil
+	}
+	t, err := parseClock(prayer, rec[i])
+	if err != nil {
+		return err
+	}
+	times[prayer] = t
+	return nil
+}
+
+// ImportICS reads a calendar with one event per prayer time, named by its
+// summary, e.g. "Fajr" or "Fajr Jamaat".
+func ImportICS(r io.Reader, opts Options) ([]Row, Mapping, error) {
+	m := Mapping{Adhan: make(map[string]string), Iqamah: make(map[string]string)}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	events, err := readEvents(r)
+	if err != nil {
+		return nil, m, err
+	}
+
+	byDate := make(map[string]*Row)
+	var order []string
+	for _, ev := range events {
+		prayer, iqamah, ok := matchSummary(ev.summary, opts)
+		if !ok {
+			continue
+		}
+		at, err := parseICSTime(ev.start, ev.tzid, loc)
+		if err != nil {
+			return nil, m, fmt.Errorf("%s: %w", ev.summary, err)
+		}
+
+		key := at.Format("2006-01-02")
+		row, ok := byDate[key]
+		if !ok {
+			r := newRow(time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC))
+			row = &r
+			byDate[key] = row
+			order = append(order, key)
+		}
+
+		if iqamah {
+			row.Iqamah[prayer] = at.Format("15:04")
+			m.Iqamah[prayer] = ev.summary
+		} else {
+			row.Adhan[prayer] = at.Format("15:04")
+			m.Adhan[prayer] = ev.summary
+		}
+	}
+
+	if len(order) == 0 {
+		return nil, m, fmt.Errorf("no prayer events found; map them with --adhan PRAYER=SUMMARY")
+	}
+
+	rows := make([]Row, 0, len(order))
+	for _, key := range order {
+		rows = append(rows, *byDate[key])
+	}
+	return rows, m, nil
+}
+
+type icsEvent struct {
+	summary string
+	start   string
+	tzid    string
+}
+
+// readEvents collects the summary and start of each VEVENT, unfolding
+// continuation lines.
+func readEvents(r io.Reader) ([]icsEvent, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading ICS: %w", err)
+	}
+
+	var events []icsEvent
+	var cur *icsEvent
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, params, _ := strings.Cut(name, ";")
+
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				cur = &icsEvent{}
+			}
+		case "END":
+			if strings.EqualFold(value, "VEVENT") && cur != nil {
+				events = append(events, *cur)
+				cur = nil
+			}
+		case "SUMMARY":
+			if cur != nil {
+				cur.summary = strings.TrimSpace(strings.ReplaceAll(value, `\,`, ","))
+			}
+		case "DTSTART":
+			if cur != nil {
+				cur.start = value
+				for _, p := range strings.Split(params, ";") {
+					if k, v, ok := strings.Cut(p, "="); ok && strings.EqualFold(k, "TZID") {
+						cur.tzid = strings.Trim(v, `"`)
+					}
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+func parseICSTime(value, tzid string, loc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad DTSTART %q", value)
+		}
+		return t.In(loc), nil
+	}
+
+	in := loc
+	if tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			in = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, in)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad DTSTART %q", value)
+	}
+	return t.In(loc), nil
+}
+
+// matchSummary finds the prayer an event is for: mapped summaries must
+// match in full, ignoring case, and others are recognised by name.
+func matchSummary(summary string, opts Options) (string, bool, bool) {
+	for p, text := range opts.Iqamah {
+		if strings.EqualFold(summary, strings.TrimSpace(text)) {
+			return p, true, true
+		}
+	}
+	for p, text := range opts.Adhan {
+		if strings.EqualFold(summary, strings.TrimSpace(text)) {
+			return p, false, true
+		}
+	}
+	return recognise(summary)
+}
+
+// recognise finds the prayer a header or summary names, and whether it is
+// a congregation time.
+func recognise(text string) (string, bool, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && r != '\''
+	})
+
+	prayer := ""
+	iqamah := false
+	for _, w := range words {
+		for p, names := range prayerNames {
+			for _, n := range names {
+				if w == n {
+					prayer = p
+				}
+			}
+		}
+		for _, iw := range iqamahWords {
+			if w == iw {
+				iqamah = true
+			}
+		}
+	}
+	return prayer, iqamah, prayer != ""
+}
+
+// CanonicalPrayer returns the prayer a user-typed name refers to.
+func CanonicalPrayer(name string) (string, bool) {
+	prayer, _, ok := recognise(name)
+	return prayer, ok
+}
+
+func isDateHeader(h string) bool {
+	switch strings.ToLower(strings.TrimSpace(h)) {
+	case "date", "gregorian", "gregorian date":
+		return true
+	}
+	return false
+}
+
+// findColumn finds col by header, ignoring case, or by 1-based number.
+func findColumn(header []string, col string) (int, error) {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(col)) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(col); err == nil && n >= 1 && n <= len(header) {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("no column %q", col)
+}
+
+// parseClock reads times such as 5:12, 05:12, 5.12 and 1:15 pm into
+// "HH:MM", moving 12-hour times without am or pm into the afternoon for
+// prayers that fall there.
+func parseClock(prayer, s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	pm := strings.HasSuffix(s, "pm") || strings.HasSuffix(s, "p")
+	am := strings.HasSuffix(s, "am") || strings.HasSuffix(s, "a")
+	s = strings.TrimSpace(strings.TrimRight(s, "apm. "))
+
+	hs, ms, ok := strings.Cut(s, ":")
+	if !ok {
+		hs, ms, ok = strings.Cut(s, ".")
+	}
+	if !ok {
+		return "", fmt.Errorf("expected a time like 5:12, got %q", s)
+	}
+	h, err1 := strconv.Atoi(hs)
+	m, err2 := strconv.Atoi(ms)
+	if err1 != nil || err2 != nil || h > 23 || m > 59 {
+		return "", fmt.Errorf("expected a time like 5:12, got %q", s)
+	}
+
+	switch {
+	case pm && h < 12:
+		h += 12
+	case am && h == 12:
+		h = 0
+	case !am && !pm:
+		switch prayer {
+		case "Dhuhr":
+			if h < 10 {
+				h += 12
+			}
+		case "Asr", "Maghrib", "Isha":
+			if h < 12 {
+				h += 12
+			}
+		}
+	}
+	return fmt.Sprintf("%02d:%02d", h, m), nil
+}
+
+// dateLayouts are tried in order when no date format is given.
+var dateLayouts = []string{
+	"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006", "02.01.2006",
+	"2 Jan 2006", "2 January 2006", "Mon 2 Jan 2006", "Monday 2 January 2006",
+	"Jan 2, 2006", "January 2, 2006", "02/01/06", "2/1/06",
+}
+
+func dateParser(format string) func(string) (time.Time, error) {
+	if format != "" {
+		layout := goLayout(format)
+		return func(s string) (time.Time, error) {
+			t, err := time.Parse(layout, s)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("date %q doesn't match %s", s, format)
+			}
+			return t, nil
+		}
+	}
+	return func(s string) (time.Time, error) {
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognised date %q; give its format with --date-format", s)
+	}
+}
+
+// goLayout turns "DD/MM/YYYY" style formats into Go's layout.
+func goLayout(format string) string {
+	r := strings.NewReplacer(
+		"YYYY", "2006", "YY", "06",
+		"MMMM", "January", "MMM", "Jan", "MM", "01", "M", "1",
+		"DD", "02", "D", "2",
+	)
+	return r.Replace(format)
+}
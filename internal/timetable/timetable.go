@@ -0,0 +1,231 @@
+// Package timetable stores a mosque's published prayer timetable and
+// imports one from the CSV and ICS files mosques hand out.
+package timetable
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DataDirName = "adhanctl"
+	FileName    = "timetable.csv"
+)
+
+// Prayers are the times a timetable may hold, in the order they are
+// stored.
+var Prayers = []string{"Imsak", "Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"}
+
+// Row is one day of a timetable. Times are "HH:MM" keyed by prayer name;
+// Iqamah only has the prayers the masjid lists a congregation time for.
+type Row struct {
+	Date   time.Time
+	Adhan  map[string]string
+	Iqamah map[string]string
+}
+
+func newRow(date time.Time) Row {
+	return Row{Date: date, Adhan: make(map[string]string), Iqamah: make(map[string]string)}
+}
+
+// DefaultPath is $XDG_DATA_HOME/adhanctl/timetable.csv.
+func DefaultPath() string {
+	var base string
+	if x := os.Getenv("XDG_DATA_HOME"); x != "" {
+		base = filepath.Join(x, DataDirName)
+	} else {
+		home := os.Getenv("HOME")
+		if home == "" {
+			home = "."
+		}
+		base = filepath.Join(home, ".local", "share", DataDirName)
+	}
+	return filepath.Join(base, FileName)
+}
+
+// Read loads a stored timetable. It has a header row, a date column in
+// YYYY-MM-DD, a HH:MM column per prayer and a PRAYER_iqamah column per
+// congregation time:
+//
+//	date,fajr,sunrise,dhuhr,asr,maghrib,isha,fajr_iqamah
+//	2025-03-01,05:12,06:41,12:19,15:21,17:52,19:14,05:45
+func Read(path string) ([]Row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no timetable at %s; run 'adhanctl timetable import'", path)
+		}
+		return nil, fmt.Errorf("reading timetable: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading timetable %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("timetable %s is empty", path)
+	}
+
+	type column struct {
+		prayer string
+		iqamah bool
+	}
+	columns := make(map[int]column)
+	dateCol := -1
+	for i, h := range records[0] {
+		h = strings.TrimSpace(h)
+		if strings.EqualFold(h, "date") {
+			dateCol = i
+			continue
+		}
+		name, iqamah := strings.CutSuffix(strings.ToLower(h), "_iqamah")
+		for _, p := range Prayers {
+			if strings.EqualFold(name, p) {
+				columns[i] = column{p, iqamah}
+			}
+		}
+	}
+	if dateCol < 0 {
+		return nil, fmt.Errorf("timetable %s has no date column", path)
+	}
+
+	var rows []Row
+	for n, rec := range records[1:] {
+		if dateCol >= len(rec) {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(rec[dateCol]))
+		if err != nil {
+			return nil, fmt.Errorf("timetable %s line %d: bad date %q", path, n+2, rec[dateCol])
+		}
+
+		row := newRow(date)
+		for i, c := range columns {
+			if i >= len(rec) || strings.TrimSpace(rec[i]) == "" {
+				continue
+			}
+			if c.iqamah {
+				row.Iqamah[c.prayer] = strings.TrimSpace(rec[i])
+			} else {
+				row.Adhan[c.prayer] = strings.TrimSpace(rec[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Write stores rows at path in date order, with a column for each prayer
+// and iqamah that any row has.
+func Write(path string, rows []Row) error {
+	rows = slices.Clone(rows)
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Date.Before(rows[j].Date) })
+
+	var adhan, iqamah []string
+	for _, p := range Prayers {
+		for _, r := range rows {
+			if _, ok := r.Adhan[p]; ok {
+				adhan = append(adhan, p)
+				break
+			}
+		}
+		for _, r := range rows {
+			if _, ok := r.Iqamah[p]; ok {
+				iqamah = append(iqamah, p)
+				break
+			}
+		}
+	}
+
+	header := []string{"date"}
+	for _, p := range adhan {
+		header = append(header, strings.ToLower(p))
+	}
+	for _, p := range iqamah {
+		header = append(header, strings.ToLower(p)+"_iqamah")
+	}
+
+	records := [][]string{header}
+	for _, r := range rows {
+		rec := []string{r.Date.Format("2006-01-02")}
+		for _, p := range adhan {
+			rec = append(rec, r.Adhan[p])
+		}
+		for _, p := range iqamah {
+			rec = append(rec, r.Iqamah[p])
+		}
+		records = append(records, rec)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("writing timetable: %w", err)
+	}
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		f.Close()
+		return fmt.Errorf("writing timetable: %w", err)
+	}
+	return f.Close()
+}
+
+// Lookup returns the row for date's calendar day.
+func Lookup(rows []Row, date time.Time) (Row, bool) {
+	day := date.Format("2006-01-02")
+	for _, r := range rows {
+		if r.Date.Format("2006-01-02") == day {
+			return r, true
+		}
+	}
+	return Row{}, false
+}
+
+// Gaps returns the dates between the first and last row that have no row.
+func Gaps(rows []Row) []time.Time {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	have := make(map[string]bool)
+	for _, r := range rows {
+		have[r.Date.Format("2006-01-02")] = true
+	}
+
+	first, last := Span(rows)
+	var gaps []time.Time
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		if !have[d.Format("2006-01-02")] {
+			gaps = append(gaps, d)
+		}
+	}
+	return gaps
+}
+
+// Span returns the first and last dates rows cover, in any order.
+func Span(rows []Row) (first, last time.Time) {
+	if len(rows) == 0 {
+		return first, last
+	}
+	first, last = rows[0].Date, rows[0].Date
+	for _, r := range rows {
+		if r.Date.Before(first) {
+			first = r.Date
+		}
+		if r.Date.After(last) {
+			last = r.Date
+		}
+	}
+	return first, last
+}
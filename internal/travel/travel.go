@@ -0,0 +1,187 @@
+package travel
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StateDirName = "adhanctl"
+	LocationFile = "location"
+	gpsdTimeout  = 5 * time.Second
+)
+
+// Hint is where the system thinks it is. Coordinates are set when they came
+// from the location file or gpsd; otherwise only Timezone is known.
+type Hint struct {
+	Timezone  string
+	Latitude  float64
+	Longitude float64
+	Source    string
+}
+
+func (h Hint) HasCoordinates() bool {
+	return h.Latitude != 0 || h.Longitude != 0
+}
+
+// Key identifies a hint closely enough to notice moves of about a kilometre.
+func (h Hint) Key() string {
+	if h.HasCoordinates() {
+		return fmt.Sprintf("%s:%.2f,%.2f", h.Source, h.Latitude, h.Longitude)
+	}
+	return "tz:" + h.Timezone
+}
+
+type Detector struct {
+	LocationFile string
+	GPSD         string
+	Logger       *slog.Logger
+}
+
+func NewDetector(locationFile, gpsd string) *Detector {
+	if locationFile == "" {
+		locationFile = DefaultLocationFile()
+	}
+	return &Detector{
+		LocationFile: locationFile,
+		GPSD:         gpsd,
+		Logger:       slog.Default(),
+	}
+}
+
+func DefaultLocationFile() string {
+	var base string
+	if x := os.Getenv("XDG_STATE_HOME"); x != "" {
+		base = filepath.Join(x, StateDirName)
+	} else {
+		home := os.Getenv("HOME")
+		if home == "" {
+			home = "."
+		}
+		base = filepath.Join(home, ".local", "state", StateDirName)
+	}
+	return filepath.Join(base, LocationFile)
+}
+
+// Current returns the most precise hint available: a gpsd fix, then the
+// location file, then the system timezone.
+func (d *Detector) Current(ctx context.Context) (Hint, bool) {
+	if d.GPSD != "" {
+		h, err := d.fromGPSD(ctx)
+		if err == nil {
+			return h, true
+		}
+		d.Logger.Debug("gpsd unavailable", "addr", d.GPSD, "error", err)
+	}
+
+	if h, err := d.fromFile(); err == nil {
+		return h, true
+	} else if !os.IsNotExist(err) {
+		d.Logger.Debug("location file unusable", "path", d.LocationFile, "error", err)
+	}
+
+	if tz := SystemTimezone(); tz != "" {
+		return Hint{Timezone: tz, Source: "timezone"}, true
+	}
+	return Hint{}, false
+}
+
+// SystemTimezone reads the host's zone from TZ, the /etc/localtime symlink
+// or /etc/timezone, without relying on time.Local, which is fixed at start.
+func SystemTimezone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && !strings.HasPrefix(tz, "/") {
+		return tz
+	}
+
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, zone, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return zone
+		}
+	}
+
+	if data, err := os.ReadFile("/etc/timezone"); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return ""
+}
+
+// fromFile reads "LAT,LON" (or "LAT LON") from the first non-comment line
+// of the location file, as written by a script or another tool.
+func (d *Detector) fromFile() (Hint, error) {
+	data, err := os.ReadFile(d.LocationFile)
+	if err != nil {
+		return Hint{}, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(fields) < 2 {
+			return Hint{}, fmt.Errorf("expected LAT,LON, got %q", line)
+		}
+		lat, err1 := strconv.ParseFloat(fields[0], 64)
+		lon, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || err2 != nil {
+			return Hint{}, fmt.Errorf("expected LAT,LON, got %q", line)
+		}
+		return Hint{Latitude: lat, Longitude: lon, Source: "file"}, nil
+	}
+
+	return Hint{}, fmt.Errorf("no coordinates in %s", d.LocationFile)
+}
+
+type gpsdReport struct {
+	Class string  `json:"class"`
+	Mode  int     `json:"mode"`
+	Lat   float64 `json:"lat"`
+	Lon   float64 `json:"lon"`
+}
+
+// fromGPSD asks a gpsd-compatible daemon for a position fix.
+func (d *Detector) fromGPSD(ctx context.Context) (Hint, error) {
+	ctx, cancel := context.WithTimeout(ctx, gpsdTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", d.GPSD)
+	if err != nil {
+		return Hint{}, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := fmt.Fprint(conn, `?WATCH={"enable":true,"json":true};`); err != nil {
+		return Hint{}, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var r gpsdReport
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if r.Class == "TPV" && r.Mode >= 2 {
+			return Hint{Latitude: r.Lat, Longitude: r.Lon, Source: "gpsd"}, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Hint{}, err
+	}
+	return Hint{}, fmt.Errorf("no fix from gpsd")
+}
@@ -6,32 +6,54 @@ import (
 	"strings"
 	"time"
 
-	"github.com/zizouhuweidi/adhanctl/internal/api"
+	"github.com/zizouhuweidi/adhanctl/internal/geo"
 	"github.com/zizouhuweidi/adhanctl/internal/prayer"
+	"github.com/zizouhuweidi/adhanctl/internal/provider"
 )
 
 type Output struct {
-	Text    string `json:"text"`
-	Tooltip string `json:"tooltip,omitempty"`
-	Class   string `json:"class,omitempty"`
+	Text    string   `json:"text"`
+	Tooltip string   `json:"tooltip,omitempty"`
+	Class   []string `json:"class,omitempty"`
 }
 
-func Build(resp *api.Response, nextEvent *prayer.Event, events []prayer.Event, ampm, arabic, short bool) Output {
-	loc := prayer.TimezoneFromResp(resp)
-	now := time.Now().In(loc)
+type Options struct {
+	AmPm       bool
+	Arabic     bool
+	Short      bool
+	Window     *prayer.Window
+	WindowWarn time.Duration
+	Makruh     *prayer.Window
+	Fasting    *prayer.FastingDay
+	Qibla      bool
+
+	Musafir      bool
+	Combinations []prayer.Combination
+}
+
+func Build(day *provider.Day, nextEvent *prayer.Event, events []prayer.Event, opts Options) Output {
+	now := time.Now().In(day.Location())
+	ampm := opts.AmPm
 
 	var text string
 	var tooltipLines []string
+	class := []string{"adhan"}
 
-	hijri := prayer.HijriString(resp, arabic)
+	hijri := prayer.HijriString(day.Hijri, day.Date.Weekday(), opts.Arabic)
 	if hijri != "" {
 		tooltipLines = append(tooltipLines, fmt.Sprintf("📅 %s", hijri))
 	}
 
+	if w := opts.Window; w != nil {
+		left := w.Remaining(now)
+		tooltipLines = append(tooltipLines, fmt.Sprintf("Now: %s — ends in %s (%s)",
+			w.Name, prayer.HumanDuration(left), prayer.FormatTime(w.End, ampm)))
+	}
+
 	if nextEvent != nil {
 		rem := prayer.HumanDuration(nextEvent.When.Sub(now))
 		timeStr := prayer.FormatTime(nextEvent.When, ampm)
-		if short {
+		if opts.Short {
 			text = fmt.Sprintf("%s %s", nextEvent.Name, timeStr)
 		} else {
 			text = fmt.Sprintf("%s %s (%s)", nextEvent.Name, timeStr, rem)
@@ -42,27 +64,66 @@ func Build(resp *api.Response, nextEvent *prayer.Event, events []prayer.Event, a
 		tooltipLines = append(tooltipLines, "No upcoming prayer")
 	}
 
+	if fast := opts.Fasting; fast != nil {
+		tooltipLines = append(tooltipLines, fmt.Sprintf("🌙 Suhoor until %s (%s), iftar at %s",
+			prayer.FormatTime(fast.Suhoor, ampm), fast.SuhoorName, prayer.FormatTime(fast.Iftar, ampm)))
+		if label, left := fast.Countdown(now); label != "" {
+			text = fmt.Sprintf("%s %s", label, prayer.HumanDuration(left))
+		}
+		class = append(class, "ramadan")
+	}
+
+	if w := opts.Window; w != nil && opts.WindowWarn > 0 && w.Remaining(now) <= opts.WindowWarn {
+		text = fmt.Sprintf("%s ends in %s", w.Name, prayer.HumanDuration(w.Remaining(now)))
+		class = append(class, "ending")
+	}
+
+	if m := opts.Makruh; m != nil {
+		tooltipLines = append(tooltipLines, fmt.Sprintf("Makruh: %s — voluntary prayer disliked until %s",
+			m.Name, prayer.FormatTime(m.End, ampm)))
+		class = append(class, "makruh")
+	}
+
+	if opts.Musafir {
+		tooltipLines = append(tooltipLines, "🧳 Musafir: shortening Dhuhr, Asr and Isha")
+		for _, c := range opts.Combinations {
+			tooltipLines = append(tooltipLines, fmt.Sprintf("  %s: %s", c.Name(), c.Spans(ampm)))
+		}
+		class = append(class, "musafir")
+	}
+
 	tooltipLines = append(tooltipLines, "", "Today's Schedule:")
-	for _, name := range prayer.StandardOrder {
-		for _, e := range events {
-			if e.Name == name {
-				marker := ""
-				if now.After(e.When) {
-					marker = " ✓"
-				} else if nextEvent != nil && e.Name == nextEvent.Name {
-					marker = " ←"
-				}
-				tooltipLines = append(tooltipLines,
-					fmt.Sprintf("  %-8s %s%s", name, prayer.FormatTime(e.When, ampm), marker))
-				break
-			}
+	for _, e := range events {
+		marker := ""
+		if now.After(e.When) {
+			marker = " ✓"
+		} else if nextEvent != nil && e.Name == nextEvent.Name {
+			marker = " ←"
 		}
+		if !e.Iqamah.IsZero() {
+			marker = fmt.Sprintf(" (iqamah %s)%s", prayer.FormatTime(e.Iqamah, ampm), marker)
+		}
+		if !e.Khutbah.IsZero() {
+			marker = fmt.Sprintf(" (khutbah %s)%s", prayer.FormatTime(e.Khutbah, ampm), marker)
+		}
+		if opts.Musafir && prayer.Qasr(e.Name) {
+			marker = " (qasr)" + marker
+		}
+		tooltipLines = append(tooltipLines,
+			fmt.Sprintf("  %-8s %s%s", e.Name, prayer.FormatTime(e.When, ampm), marker))
+	}
+
+	if opts.Qibla && day.HasCoordinates() {
+		q := geo.QiblaFrom(day.Latitude, day.Longitude)
+		tooltipLines = append(tooltipLines, "",
+			fmt.Sprintf("🧭 Qibla %.0f° %s — %.0f km", q.Bearing, geo.CompassPoint(q.Bearing), q.Distance),
+			geo.Rose(q.Bearing))
 	}
 
 	return Output{
 		Text:    text,
 		Tooltip: strings.Join(tooltipLines, "\n"),
-		Class:   "adhan",
+		Class:   class,
 	}
 }
 